@@ -1,6 +1,10 @@
 package asr
 
-import "context"
+import (
+	"context"
+
+	"github.com/K3das/orange/media"
+)
 
 type SpeechRecognitionAPI interface {
 	Run(ctx context.Context, data []byte) (*ASROutput, error)
@@ -9,4 +13,42 @@ type SpeechRecognitionAPI interface {
 type ASROutput struct {
 	Text      string
 	ModelName string
+
+	// Segments of the text with timestamps relative to the start of the
+	// input, if the provider returns them
+	Segments []Segment
+}
+
+type Segment struct {
+	// Start and End in seconds
+	Start float64
+	End   float64
+	Text  string
+
+	// HasConfidence is set if the provider returned AvgLogProb and
+	// NoSpeechProb
+	HasConfidence bool
+	AvgLogProb    float64
+	NoSpeechProb  float64
+}
+
+// HealthChecker is implemented by SpeechRecognitionAPIs that can check
+// whether the provider is reachable without running a transcription.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// EncodingProfiler is implemented by SpeechRecognitionAPIs that prefer audio
+// in a specific encoding.
+type EncodingProfiler interface {
+	EncodingProfile() media.EncodingProfile
+}
+
+// PreferredEncodingProfile returns the profile the API prefers, or
+// media.DefaultEncodingProfile.
+func PreferredEncodingProfile(api SpeechRecognitionAPI) media.EncodingProfile {
+	if profiler, ok := api.(EncodingProfiler); ok {
+		return profiler.EncodingProfile()
+	}
+	return media.DefaultEncodingProfile
 }
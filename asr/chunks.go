@@ -0,0 +1,86 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/K3das/orange/media"
+	"golang.org/x/sync/errgroup"
+)
+
+// RunChunks transcribes chunks of audio concurrently, running at most
+// parallelism at once, and stitches the results.
+func RunChunks(ctx context.Context, api SpeechRecognitionAPI, chunks []media.AudioChunk, parallelism int) (*ASROutput, error) {
+	outputs := make([]*ASROutput, len(chunks))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for i, chunk := range chunks {
+		g.Go(func() error {
+			output, err := api.Run(gCtx, chunk.Data)
+			if err != nil {
+				return fmt.Errorf("chunk at %fs: %w", chunk.Start, err)
+			}
+			outputs[i] = output
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return stitchChunks(chunks, outputs), nil
+}
+
+// stitchChunks joins chunk outputs, offsetting segment timestamps by the
+// chunk's start. Where chunks overlap, segments are cut at the middle of the
+// overlap by their midpoint, so text isn't repeated. Chunks without segments
+// are used as is.
+func stitchChunks(chunks []media.AudioChunk, outputs []*ASROutput) *ASROutput {
+	stitched := &ASROutput{}
+	var texts []string
+
+	for i, output := range outputs {
+		if stitched.ModelName == "" {
+			stitched.ModelName = output.ModelName
+		}
+
+		lower := math.Inf(-1)
+		if i > 0 {
+			lower = (chunks[i-1].End + chunks[i].Start) / 2
+		}
+		upper := math.Inf(1)
+		if i < len(chunks)-1 {
+			upper = (chunks[i].End + chunks[i+1].Start) / 2
+		}
+
+		if len(output.Segments) == 0 {
+			if text := strings.TrimSpace(output.Text); text != "" {
+				texts = append(texts, text)
+			}
+			continue
+		}
+
+		for _, segment := range output.Segments {
+			segment.Start += chunks[i].Start
+			segment.End += chunks[i].Start
+
+			middle := (segment.Start + segment.End) / 2
+			if middle < lower || middle >= upper {
+				continue
+			}
+
+			stitched.Segments = append(stitched.Segments, segment)
+			if text := strings.TrimSpace(segment.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	stitched.Text = strings.Join(texts, " ")
+	return stitched
+}
@@ -0,0 +1,150 @@
+package asr
+
+import (
+	"strings"
+	"unicode"
+)
+
+type LowConfidenceReason string
+
+const (
+	LowConfidenceRepetition    = LowConfidenceReason("repetition")
+	LowConfidenceHallucination = LowConfidenceReason("hallucination")
+	LowConfidenceLogProb       = LowConfidenceReason("low_log_prob")
+	LowConfidenceNoSpeech      = LowConfidenceReason("no_speech")
+)
+
+const (
+	// segments averaging below this are likely wrong, Whisper itself
+	// retries decoding below -1
+	minAvgLogProb = -1.0
+	// segments averaging above this are likely transcribed noise
+	maxNoSpeechProb = 0.6
+
+	// the longest phrase checked for repetition, in words
+	maxRepeatedPhraseWords = 8
+	// a phrase repeated back to back this many words' worth is a loop, so
+	// short phrases need more repeats
+	minRepeatedWords = 12
+	minRepeats       = 4
+)
+
+// knownHallucinations are phrases Whisper produces from silence or noise,
+// usually from subtitles in its training data. They're compared against the
+// normalized text of the whole transcript.
+var knownHallucinations = []string{
+	"you",
+	"thank you for watching",
+	"thanks for watching",
+	"thank you so much for watching",
+	"please subscribe",
+	"please like and subscribe",
+	"dont forget to like and subscribe",
+	"subtitles by the amaraorg community",
+	"subtitles by",
+	"transcription by castingwords",
+	"translated by",
+	"see you in the next video",
+	"see you next time",
+}
+
+// normalizeWords lowercases the text and splits it into words without
+// punctuation.
+func normalizeWords(text string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// hasRepetitionLoop reports whether a phrase is repeated back to back enough
+// to be a decoding loop.
+func hasRepetitionLoop(words []string) bool {
+	for n := 1; n <= maxRepeatedPhraseWords; n++ {
+		needed := max(minRepeats, (minRepeatedWords+n-1)/n)
+
+		for i := 0; i+n*needed <= len(words); i++ {
+			repeats := 1
+			for j := i + n; j+n <= len(words) && phraseEqual(words[i:i+n], words[j:j+n]); j += n {
+				repeats++
+			}
+			if repeats >= needed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func phraseEqual(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isKnownHallucination reports whether the transcript is only made of known
+// hallucinated phrases.
+func isKnownHallucination(words []string) bool {
+	text := strings.Join(words, " ")
+	if text == "" {
+		return false
+	}
+
+	for text != "" {
+		matched := false
+		for _, phrase := range knownHallucinations {
+			if rest, ok := strings.CutPrefix(text, phrase); ok && (rest == "" || rest[0] == ' ') {
+				text = strings.TrimSpace(rest)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// LowConfidence checks the output for signs it isn't real speech: known
+// hallucinated phrases, repetition loops, and low average log probability or
+// high no-speech probability where the provider gives them. It returns the
+// reason, or an empty string if the output looks fine.
+func LowConfidence(output *ASROutput) LowConfidenceReason {
+	words := normalizeWords(output.Text)
+
+	if isKnownHallucination(words) {
+		return LowConfidenceHallucination
+	}
+	if hasRepetitionLoop(words) {
+		return LowConfidenceRepetition
+	}
+
+	var logProb, noSpeech, duration float64
+	for _, segment := range output.Segments {
+		if !segment.HasConfidence {
+			continue
+		}
+		// weighted by length, so short segments don't skew it
+		length := max(segment.End-segment.Start, 0.01)
+		logProb += segment.AvgLogProb * length
+		noSpeech += segment.NoSpeechProb * length
+		duration += length
+	}
+	if duration > 0 {
+		if noSpeech/duration > maxNoSpeechProb {
+			return LowConfidenceNoSpeech
+		}
+		if logProb/duration < minAvgLogProb {
+			return LowConfidenceLogProb
+		}
+	}
+
+	return ""
+}
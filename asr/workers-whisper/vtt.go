@@ -0,0 +1,69 @@
+package workerswhisper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/K3das/orange/asr"
+)
+
+// parseVTTTimestamp parses `HH:MM:SS.mmm` or `MM:SS.mmm` into seconds.
+func parseVTTTimestamp(timestamp string) (float64, error) {
+	parts := strings.Split(timestamp, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	var seconds float64
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
+
+// parseVTT parses the cues of a WebVTT transcript into segments, skipping
+// cues it can't parse.
+func parseVTT(vtt string) []asr.Segment {
+	var segments []asr.Segment
+
+	lines := strings.Split(strings.ReplaceAll(vtt, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		startRaw, endRaw, ok := strings.Cut(lines[i], "-->")
+		if !ok {
+			continue
+		}
+
+		// cue settings can follow the end timestamp
+		endFields := strings.Fields(endRaw)
+		if len(endFields) == 0 {
+			continue
+		}
+		start, err := parseVTTTimestamp(strings.TrimSpace(startRaw))
+		if err != nil {
+			continue
+		}
+		end, err := parseVTTTimestamp(endFields[0])
+		if err != nil {
+			continue
+		}
+
+		var text []string
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			text = append(text, strings.TrimSpace(lines[i]))
+		}
+
+		segments = append(segments, asr.Segment{
+			Start: start,
+			End:   end,
+			Text:  strings.Join(text, " "),
+		})
+	}
+
+	return segments
+}
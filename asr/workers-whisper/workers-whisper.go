@@ -8,6 +8,9 @@ import (
 	"net/http"
 
 	"github.com/K3das/orange/asr"
+	"github.com/K3das/orange/media"
+	"github.com/K3das/orange/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // used for the model name in the database
@@ -60,8 +63,14 @@ func NewWorkersWhisperClient(options WorkersWhisperClientOptions) *WorkersWhispe
 	}
 }
 
-func (w *WorkersWhisperClient) runCF(ctx context.Context, data []byte) (*CloudflareResponse[SpeechRecognitionResponse], error) {
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://api.cloudflare.com/client/v4/accounts/%s/ai/run/%s", w.account, w.model), bytes.NewBuffer(data))
+func (w *WorkersWhisperClient) runCF(ctx context.Context, data []byte) (cfResp *CloudflareResponse[SpeechRecognitionResponse], err error) {
+	ctx, span := tracing.Start(ctx, "workers_whisper.run",
+		attribute.String("asr.model", w.model),
+		attribute.Int("asr.input_size", len(data)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://api.cloudflare.com/client/v4/accounts/%s/ai/run/%s", w.account, w.model), bytes.NewBuffer(data))
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -78,7 +87,6 @@ func (w *WorkersWhisperClient) runCF(ctx context.Context, data []byte) (*Cloudfl
 		return nil, fmt.Errorf("non-ok http response: [%d] %s", resp.StatusCode, resp.Status)
 	}
 
-	var cfResp *CloudflareResponse[SpeechRecognitionResponse]
 	err = json.NewDecoder(resp.Body).Decode(&cfResp)
 	if err != nil {
 		return nil, fmt.Errorf("decoding response json: %w", err)
@@ -87,6 +95,12 @@ func (w *WorkersWhisperClient) runCF(ctx context.Context, data []byte) (*Cloudfl
 	return cfResp, nil
 }
 
+// EncodingProfile is 16kHz since that's what Whisper runs at, which also
+// makes uploads smaller.
+func (w *WorkersWhisperClient) EncodingProfile() media.EncodingProfile {
+	return media.ProfileAAC16kMono
+}
+
 func (w *WorkersWhisperClient) Run(ctx context.Context, data []byte) (*asr.ASROutput, error) {
 	resp, err := w.runCF(ctx, data)
 	if err != nil {
@@ -103,5 +117,46 @@ func (w *WorkersWhisperClient) Run(ctx context.Context, data []byte) (*asr.ASROu
 	return &asr.ASROutput{
 		ModelName: apiPrefix + w.model,
 		Text:      resp.Result.Text,
+		Segments:  parseVTT(resp.Result.Vtt),
 	}, nil
 }
+
+type tokenVerifyResponse struct {
+	Status string `json:"status"`
+}
+
+// HealthCheck verifies the API token, which checks that Cloudflare is
+// reachable and the token is still active.
+func (w *WorkersWhisperClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.cloudflare.com/client/v4/user/tokens/verify", nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+w.token)
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-ok http response: [%d] %s", resp.StatusCode, resp.Status)
+	}
+
+	var cfResp *CloudflareResponse[tokenVerifyResponse]
+	err = json.NewDecoder(resp.Body).Decode(&cfResp)
+	if err != nil {
+		return fmt.Errorf("decoding response json: %w", err)
+	}
+
+	if !cfResp.Success || cfResp.Result == nil {
+		return fmt.Errorf("request unsuccessful")
+	}
+	if cfResp.Result.Status != "active" {
+		return fmt.Errorf("token is %s", cfResp.Result.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const httpShutdownTimeout = 5 * time.Second
+const readinessCheckTimeout = 5 * time.Second
+
+// how long ASR provider health checks are cached, so readiness probes don't
+// hit its API every time
+const asrHealthCheckInterval = time.Minute
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type httpServer struct {
+	log    *zap.Logger
+	server *http.Server
+
+	readinessChecks []readinessCheck
+}
+
+func newHTTPServer(parentLogger *zap.Logger, address string, collectors ...prometheus.Collector) *httpServer {
+	for _, collector := range collectors {
+		prometheus.MustRegister(collector)
+	}
+
+	s := &httpServer{
+		log: parentLogger.Named("http"),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+
+	s.server = &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+// AddReadinessCheck adds a check to /readyz, which is ready only when all
+// checks pass. It must be called before Run.
+func (s *httpServer) AddReadinessCheck(name string, check func(ctx context.Context) error) {
+	s.readinessChecks = append(s.readinessChecks, readinessCheck{
+		name:  name,
+		check: check,
+	})
+}
+
+func (s *httpServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+func (s *httpServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(s.readinessChecks))
+	ready := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range s.readinessChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := "ok"
+			err := c.check(ctx)
+			if err != nil {
+				s.log.With(zap.String("check", c.name), zap.Error(err)).Warn("readiness check failed")
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = result
+			if err != nil {
+				ready = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(results)
+}
+
+// cachedCheck wraps check so it runs at most once per ttl, for checks that
+// call external APIs. Concurrent calls share a run instead of queueing behind
+// each other.
+func cachedCheck(ttl time.Duration, check func(ctx context.Context) error) func(ctx context.Context) error {
+	var mu sync.Mutex
+	var lastErr error
+	var lastRun time.Time
+	var group singleflight.Group
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		if !lastRun.IsZero() && time.Since(lastRun) < ttl {
+			err := lastErr
+			mu.Unlock()
+			return err
+		}
+		mu.Unlock()
+
+		result := group.DoChan("", func() (any, error) {
+			// shared by every caller waiting on it, so it doesn't stop when
+			// the one that started it gives up
+			checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), readinessCheckTimeout)
+			defer cancel()
+
+			err := check(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			lastErr = err
+			lastRun = time.Now()
+			return nil, err
+		})
+
+		select {
+		case r := <-result:
+			return r.Err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Run serves until ctx is done, then shuts the server down.
+func (s *httpServer) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		s.log.With(zap.String("address", s.server.Addr)).Info("listening")
+		errCh <- s.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+
+	err := s.server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+
+	err = <-errCh
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving: %w", err)
+	}
+	return nil
+}
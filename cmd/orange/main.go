@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/K3das/orange/asr"
 	workerswhisper "github.com/K3das/orange/asr/workers-whisper"
 	"github.com/K3das/orange/discord"
+	"github.com/K3das/orange/media"
 	"github.com/K3das/orange/messages"
 	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/tracing"
 	"github.com/caarlos0/env/v9"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -19,12 +24,63 @@ import (
 var CommitHash = ""
 
 type config struct {
+	// A Postgres DSN, or sqlite:PATH for SQLite
 	PostgresDSN string `env:"POSTGRES_DSN,required"`
+	// Apply migrations on startup, disable to run them once with the migrate
+	// subcommand instead
+	AutoMigrate bool `env:"AUTO_MIGRATE" envDefault:"true"`
+
+	// Enables opt-in transcript history, the key encrypts stored transcripts
+	TranscriptEncryptionKey string `env:"TRANSCRIPT_ENCRYPTION_KEY"`
+
+	Retention store.RetentionOptions `envPrefix:"RETENTION_"`
 
 	DiscordToken string   `env:"DISCORD_TOKEN,required"`
 	Servers      []string `env:"SERVERS,required"`
+	// User IDs that can see bot-wide stats
+	Admins []string `env:"ADMINS"`
+
+	Transcription transcriptionConfig
+
+	RateLimits discord.RateLimitOptions `envPrefix:"RATE_LIMIT_"`
+
+	// Optional directory of Jsonnet templates layered over the built-in ones,
+	// reloaded when they change
+	TemplateDir string `env:"TEMPLATE_DIR"`
+
+	// USD per minute of audio by model name, for estimating costs in /stats
+	ASRPrices map[string]float64 `env:"ASR_PRICE_PER_MINUTE"`
 
+	// Serves /metrics, /healthz and /readyz
+	HTTPAddress string `env:"HTTP_ADDRESS" envDefault:":3926"`
+
+	Tracing tracing.Options `envPrefix:"TRACING_"`
+}
+
+// transcriptionConfig configures the audio pipeline, shared by the bot and
+// the transcribe subcommand
+type transcriptionConfig struct {
 	WorkersWhisperOptions workerswhisper.WorkersWhisperClientOptions `envPrefix:"ASR_WORKERS_WHISPER_"`
+
+	Preprocess media.PreprocessOptions `envPrefix:"PREPROCESS_"`
+
+	// Limits for ffmpeg and ffprobe processes, 0 is unlimited
+	FFmpegMaxConcurrency int    `env:"FFMPEG_MAX_CONCURRENCY" envDefault:"4"`
+	FFmpegNiceness       int    `env:"FFMPEG_NICENESS" envDefault:"10"`
+	FFmpegMemoryLimit    uint64 `env:"FFMPEG_MEMORY_LIMIT_BYTES"`
+}
+
+func (c transcriptionConfig) newASRClient() *workerswhisper.WorkersWhisperClient {
+	return workerswhisper.NewWorkersWhisperClient(c.WorkersWhisperOptions)
+}
+
+func (c transcriptionConfig) newFFmpeg() *media.FFmpeg {
+	return media.NewFFmpeg(
+		media.WithPreprocessing(c.Preprocess),
+		media.WithMaxConcurrency(c.FFmpegMaxConcurrency),
+		media.WithNiceness(c.FFmpegNiceness),
+		media.WithMemoryLimit(c.FFmpegMemoryLimit),
+	)
 }
 
 const environmentPrefix = "ORANGE_"
@@ -58,7 +114,28 @@ func createLog() *zap.Logger {
 	return rawLog
 }
 
+// subcommands run instead of the bot when named by the first argument
+var subcommands = map[string]func(args []string) error{
+	"migrate":    runMigrate,
+	"render":     runRender,
+	"transcribe": runTranscribe,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			if err := subcommand(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	runBot()
+}
+
+func runBot() {
 	parentLogger := createLog()
 	defer parentLogger.Sync()
 
@@ -72,35 +149,104 @@ func main() {
 		log.Fatal("failed to parse config", zap.Error(err))
 	}
 
-	s := store.NewStore(context.Background(), parentLogger)
-	err := s.Connect(context.Background(), cfg.PostgresDSN)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, CommitHash)
+	if err != nil {
+		log.Fatal("failed to set up tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush traces", zap.Error(err))
+		}
+	}()
+
+	s := store.NewStore(context.Background(), parentLogger,
+		store.WithTranscriptKey(cfg.TranscriptEncryptionKey),
+		store.WithAutoMigrate(cfg.AutoMigrate),
+	)
+	err = s.Connect(context.Background(), cfg.PostgresDSN)
 	if err != nil {
 		log.Fatal("failed to connect store", zap.Error(err))
 	}
 
-	messageProvider, err := messages.NewMessageProvider()
+	messageProvider, err := messages.NewMessageProvider(
+		messages.WithLogger(parentLogger),
+		messages.WithTemplateDir(cfg.TemplateDir),
+		messages.WithValidator(discord.ValidateTemplates),
+	)
 	if err != nil {
 		log.Fatal("failed to create message provider", zap.Error(err))
 	}
 
-	asrClient := workerswhisper.NewWorkersWhisperClient(cfg.WorkersWhisperOptions)
+	asrClient := cfg.Transcription.newASRClient()
+	ffmpeg := cfg.Transcription.newFFmpeg()
 
 	discordBot, err := discord.NewDiscordBot(context.Background(), discord.DiscordBotOptions{
-		Token:        cfg.DiscordToken,
-		Servers:      cfg.Servers,
-		ParentLogger: parentLogger,
-		Store:        s,
-		Messages:     messageProvider,
-		ASR:          asrClient,
-	})
+		Token:          cfg.DiscordToken,
+		Servers:        cfg.Servers,
+		ParentLogger:   parentLogger,
+		Users:          s,
+		Transcriptions: s,
+		GuildSettings:  s,
+		History:        s,
+		Privacy:        s,
+		Messages:       messageProvider,
+		ASR:            asrClient,
+	},
+		discord.WithFFmpeg(ffmpeg),
+		discord.WithRateLimits(cfg.RateLimits),
+		discord.WithBotAdmins(cfg.Admins),
+		discord.WithASRPrices(cfg.ASRPrices),
+	)
 	if err != nil {
 		log.Fatal("failed to create discord bot", zap.Error(err))
 	}
 
+	httpServer := newHTTPServer(parentLogger, cfg.HTTPAddress, s.Collector())
+	httpServer.AddReadinessCheck("database", s.Ping)
+	httpServer.AddReadinessCheck("discord_gateway", func(ctx context.Context) error {
+		if !discordBot.Ready() {
+			return fmt.Errorf("not connected")
+		}
+		return nil
+	})
+	httpServer.AddReadinessCheck("ffmpeg", func(ctx context.Context) error {
+		return ffmpeg.CheckBinaries()
+	})
+	if checker, ok := any(asrClient).(asr.HealthChecker); ok {
+		httpServer.AddReadinessCheck("asr", cachedCheck(asrHealthCheckInterval, checker.HealthCheck))
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	g := errgroup.Group{}
 
+	// Template reloading
+	g.Go(func() error {
+		// the bot keeps working with the last loaded templates
+		if err := messageProvider.Watch(ctx); err != nil {
+			log.Error("template watcher stopped", zap.Error(err))
+		}
+		return nil
+	})
+
+	// Pruning old transcriptions
+	g.Go(func() error {
+		// stopping retention doesn't affect the bot
+		if err := s.RunRetention(ctx, cfg.Retention); err != nil {
+			log.Error("retention job stopped", zap.Error(err))
+		}
+		return nil
+	})
+
+	// Metrics and health server
+	g.Go(func() error {
+		defer cancel()
+
+		return httpServer.Run(ctx)
+	})
+
 	// Discord bot
 	g.Go(func() error {
 		defer cancel()
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/K3das/orange/store"
+	"github.com/caarlos0/env/v9"
+)
+
+const migrateUsage = `usage: orange migrate <command>
+
+Manages the database schema, connecting with ORANGE_POSTGRES_DSN.
+
+commands:
+  status          print the applied and latest migration versions
+  up [N]          apply N migrations, or all of them
+  down N          roll back N migrations
+  force VERSION   set the version without running migrations, after fixing a
+                  dirty migration by hand. -1 means none are applied.
+`
+
+// runMigrate manages migrations out of band, for deployments that disable
+// ORANGE_AUTO_MIGRATE.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), migrateUsage)
+	}
+	flags.Parse(args)
+
+	command := flags.Arg(0)
+	var n int
+	switch {
+	case command == "status" && flags.NArg() == 1:
+	case command == "up" && flags.NArg() <= 2,
+		command == "down" && flags.NArg() == 2,
+		command == "force" && flags.NArg() == 2:
+		if flags.NArg() == 2 {
+			var err error
+			n, err = strconv.Atoi(flags.Arg(1))
+			if err != nil {
+				return fmt.Errorf("invalid number: %s", flags.Arg(1))
+			}
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := struct {
+		PostgresDSN string `env:"POSTGRES_DSN,required"`
+	}{}
+	if err := env.ParseWithOptions(&cfg, env.Options{
+		Prefix: environmentPrefix,
+	}); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	parentLogger := createLog()
+	defer parentLogger.Sync()
+
+	s := store.NewStore(context.Background(), parentLogger, store.WithoutMigrations())
+	err := s.Connect(context.Background(), cfg.PostgresDSN)
+	if err != nil {
+		return fmt.Errorf("connecting store: %w", err)
+	}
+	defer s.Close()
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		dirty := ""
+		if status.Dirty {
+			dirty = " (dirty)"
+		}
+		fmt.Printf("version: %d%s\nlatest:  %d\n", status.Version, dirty, status.Latest)
+		return nil
+	case "up":
+		if n < 0 {
+			return fmt.Errorf("invalid number: %d", n)
+		}
+		return migrator.Up(n)
+	case "down":
+		return migrator.Down(n)
+	case "force":
+		return migrator.Force(n)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/K3das/orange/discord"
+	"github.com/K3das/orange/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+const renderUsage = `usage: orange render [flags] <message> [context.json]
+
+Renders a message template and prints the Discord payload. The context is a
+JSON MessageContext, read from stdin if the file is "-". Without one, one of
+the built-in fixtures for the message is used.
+
+`
+
+// runRender renders a message template locally, for designing messages
+// without triggering them in Discord.
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), renderUsage)
+		flags.PrintDefaults()
+	}
+	templateDir := flags.String("template-dir", os.Getenv(environmentPrefix+"TEMPLATE_DIR"), "directory of templates layered over the built-in ones")
+	locale := flags.String("locale", "", "Discord locale to render in, such as pt-BR")
+	fixture := flags.Int("fixture", 0, "which built-in fixture to use without a context file")
+	webhookURL := flags.String("webhook", "", "webhook URL to post the message to for a preview")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	messageName := flags.Arg(0)
+
+	messageProvider, err := messages.NewMessageProvider(
+		messages.WithPoolSize(1),
+		messages.WithTemplateDir(*templateDir),
+	)
+	if err != nil {
+		return fmt.Errorf("loading templates: %w", err)
+	}
+
+	var data discord.MessageContext
+	if flags.NArg() == 2 {
+		data, err = readMessageContext(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+	} else {
+		fixtures := discord.TemplateFixtures()[messageName]
+		if *fixture < 0 || *fixture >= len(fixtures) {
+			return fmt.Errorf("%s has %d fixtures, pass a context file instead", messageName, len(fixtures))
+		}
+		data = fixtures[*fixture]
+	}
+
+	if *locale != "" {
+		data.Locale = *locale
+	}
+	if data.Timestamp == "" {
+		data.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	if data.RegisteredCommands == nil {
+		data.RegisteredCommands = discord.FixtureCommands
+	}
+
+	jsonOut, err := messageProvider.ExecuteMessage(messageName, data)
+	if err != nil {
+		return fmt.Errorf("rendering %s: %w", messageName, err)
+	}
+	output, err := discord.DecodeMessageOutput(jsonOut)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", messageName, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(output)
+	if err != nil {
+		return fmt.Errorf("printing output: %w", err)
+	}
+
+	if *webhookURL != "" {
+		err = postWebhookPreview(*webhookURL, output)
+		if err != nil {
+			return fmt.Errorf("posting to webhook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readMessageContext(path string) (discord.MessageContext, error) {
+	var data discord.MessageContext
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return data, fmt.Errorf("opening context: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return data, fmt.Errorf("decoding context: %w", err)
+	}
+
+	return data, nil
+}
+
+// postWebhookPreview sends a message to a webhook URL, as shown by Discord
+// or create-owned-hook. Interactive components only work on webhooks owned
+// by the application.
+func postWebhookPreview(webhookURL string, output *discord.MessageOutput) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[len(parts)-3] != "webhooks" {
+		return fmt.Errorf("not a webhook url: %s", webhookURL)
+	}
+	webhookID, token := parts[len(parts)-2], parts[len(parts)-1]
+
+	session, err := discordgo.New("")
+	if err != nil {
+		return fmt.Errorf("creating session: %w", err)
+	}
+
+	_, err = session.WebhookExecute(webhookID, token, true, &discordgo.WebhookParams{
+		Content:         output.Content,
+		Components:      output.Components,
+		Embeds:          output.Embeds,
+		AllowedMentions: discord.DefaultAllowedMentions,
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/K3das/orange/asr"
+	"github.com/K3das/orange/discord"
+	"github.com/K3das/orange/media"
+	"github.com/caarlos0/env/v9"
+)
+
+const transcribeUsage = `usage: orange transcribe [flags] <audio file>
+
+Runs an audio file through the same pipeline as voice messages, without
+Discord or Postgres, and prints the transcript with timings. ASR and ffmpeg
+are configured from the same ORANGE_ environment variables as the bot.
+
+`
+
+type transcribeTiming struct {
+	stage    string
+	duration time.Duration
+}
+
+// runTranscribe transcribes a local file, for debugging transcription
+// quality without sending voice messages.
+func runTranscribe(args []string) error {
+	flags := flag.NewFlagSet("transcribe", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), transcribeUsage)
+		flags.PrintDefaults()
+	}
+	showSegments := flags.Bool("segments", false, "print segments with timestamps and confidence")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+
+	cfg := transcriptionConfig{}
+	if err := env.ParseWithOptions(&cfg, env.Options{
+		Prefix: environmentPrefix,
+	}); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	asrClient := cfg.newASRClient()
+	ffmpeg := cfg.newFFmpeg()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, discord.TranscriptionTimeout)
+	defer cancel()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+
+	var timings []transcribeTiming
+	start := time.Now()
+	stageStart := start
+	endStage := func(stage string) {
+		timings = append(timings, transcribeTiming{stage, time.Since(stageStart)})
+		stageStart = time.Now()
+	}
+
+	oggParser := &media.OggOpusParser{}
+	profile := asr.PreferredEncodingProfile(asrClient)
+	resampled, err := ffmpeg.FFmpegProbeAndResampleAudio(ctx, io.TeeReader(f, oggParser), profile, discord.MaxInputFileSize, discord.MaxOutputFileSize)
+	if err != nil {
+		return fmt.Errorf("resampling: %w", err)
+	}
+	duration, durationSource := resampled.Duration, "ffmpeg"
+	if oggInfo, err := oggParser.Info(); err == nil {
+		duration, durationSource = oggInfo.Duration, "ogg opus"
+	}
+	endStage("resample")
+	if duration > discord.MaxDuration {
+		return fmt.Errorf("file too long: %.2f s, the limit is %d s", duration, discord.MaxDuration)
+	}
+
+	silent := resampled.Silent
+
+	output := &asr.ASROutput{}
+	if !silent {
+		output, err = discord.TranscribeAudio(ctx, ffmpeg, asrClient, resampled.Data, resampled.Duration, profile)
+		if err != nil {
+			return fmt.Errorf("transcribing: %w", err)
+		}
+		endStage("asr")
+	}
+
+	fmt.Printf("file:       %s\n", path)
+	fmt.Printf("duration:   %.2f s (%s), %.2f s resampled as %s\n", duration, durationSource, resampled.Duration, profile.Codec)
+	if silent {
+		fmt.Println("model:      none, no speech detected")
+	} else {
+		fmt.Printf("model:      %s\n", output.ModelName)
+	}
+	if reason := asr.LowConfidence(output); !silent && reason != "" {
+		fmt.Printf("confidence: low (%s)\n", reason)
+	}
+
+	stages := make([]string, 0, len(timings))
+	for _, timing := range timings {
+		stages = append(stages, fmt.Sprintf("%s %.2f s", timing.stage, timing.duration.Seconds()))
+	}
+	fmt.Printf("timings:    %s · total %.2f s\n", strings.Join(stages, " · "), time.Since(start).Seconds())
+
+	if *showSegments {
+		fmt.Println("segments:")
+		for _, segment := range output.Segments {
+			confidence := ""
+			if segment.HasConfidence {
+				confidence = fmt.Sprintf(" (avg log prob %.2f, no speech %.2f)", segment.AvgLogProb, segment.NoSpeechProb)
+			}
+			fmt.Printf("  %7.2f–%7.2f %s%s\n", segment.Start, segment.End, strings.TrimSpace(segment.Text), confidence)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(output.Text)
+
+	return nil
+}
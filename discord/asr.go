@@ -4,25 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/K3das/orange/asr"
+	"github.com/K3das/orange/media"
+	"github.com/K3das/orange/metrics"
+	"github.com/K3das/orange/store"
 	"github.com/K3das/orange/store/db"
+	"github.com/K3das/orange/tracing"
 	"github.com/K3das/orange/utils"
 	"github.com/bwmarrin/discordgo"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // max attachment file size in bytes
-const MaxInputFileSize = 1024 * 1024 * 4
-const MaxOutputFileSize = 1024 * 1024 * 10
+const MaxInputFileSize = 1024 * 1024 * 8
+const MaxOutputFileSize = 1024 * 1024 * 24
 
 // the hard limit for the number of seconds audio can be before it's not transcribed
-const MaxDuration = 600
+const MaxDuration = 1800
+
+// how many chunks of long audio are transcribed at once
+const ChunkParallelism = 4
+
+// how long a transcription can take, long audio is split into chunks so this
+// doesn't need to scale with MaxDuration
+const TranscriptionTimeout = time.Minute * 5
 
 const (
 	ComponentActionASREnable  = ComponentIDAction("asr_enable")
@@ -44,21 +57,38 @@ func (b *DiscordBot) handleMessageCreateASR(ctx context.Context, e *discordgo.Me
 		return nil // wrong mime type
 	}
 
+	metrics.VoiceMessagesSeen.Inc()
+
+	ctx, span := tracing.Start(ctx, "voice_message",
+		attribute.String("discord.guild_id", e.GuildID),
+		attribute.String("discord.channel_id", e.ChannelID),
+		attribute.String("discord.message_id", e.ID),
+		attribute.Float64("voice_message.duration", attachment.DurationSecs),
+	)
+	defer span.End()
+	log = utils.GetLogFromContext(ctx, b.log)
+
 	if attachment.Size > MaxInputFileSize {
 		log.With(zap.Int("attachment_size", attachment.Size)).Info("voice message too big")
+		metrics.SkipVoiceMessage(metrics.SkipReasonTooBig)
 		return nil
 	}
 
 	if attachment.DurationSecs > MaxDuration {
 		log.With(zap.Float64("duration", attachment.DurationSecs)).Info("voice message too long")
+		metrics.SkipVoiceMessage(metrics.SkipReasonTooLong)
 		return nil
 	}
 
-	member, err := b.store.GetOrCreateUser(context.Background(), e.Author.ID)
+	member, err := b.users.GetOrCreateUser(ctx, e.Author.ID)
 	if err != nil {
 		return fmt.Errorf("getting member: %w", err)
 	}
 
+	if !member.AsrEnabled {
+		metrics.SkipVoiceMessage(metrics.SkipReasonNotOptedIn)
+	}
+
 	if !member.AsrEnabled && !member.AsrNudged && !member.AsrNudgedTouchedAt.Valid {
 		err = b.sendASRNudge(ctx, e)
 		if err != nil {
@@ -69,6 +99,31 @@ func (b *DiscordBot) handleMessageCreateASR(ctx context.Context, e *discordgo.Me
 		return nil
 	}
 
+	limited, releaseQuota, err := b.checkTranscriptionLimits(ctx, e, attachment.DurationSecs)
+	if err != nil {
+		return fmt.Errorf("checking limits: %w", err)
+	}
+	if limited != nil {
+		log.With(
+			zap.String("reason", string(limited.Reason)),
+			zap.Duration("retry_after", limited.RetryAfter),
+		).Info("voice message rate limited")
+		metrics.SkipVoiceMessage(metrics.SkipReasonRateLimited)
+
+		err = b.sendRateLimitNotice(ctx, e, limited)
+		if err != nil {
+			return fmt.Errorf("sending rate limit notice: %w", err)
+		}
+		return nil
+	}
+
+	transcribing := false
+	defer func() {
+		if !transcribing {
+			releaseQuota()
+		}
+	}()
+
 	renderedProgress, err := b.executeMessageTemplate(ctx, "asr_progress", MessageContext{
 		AsrProgress: &MessageContextAsrProgress{},
 	})
@@ -87,24 +142,37 @@ func (b *DiscordBot) handleMessageCreateASR(ctx context.Context, e *discordgo.Me
 		return fmt.Errorf("sending initial reply message: %w", err)
 	}
 
+	transcribing = true
 	go func() {
 		defer utils.PanicRecovery(log)
-
-		ctx := utils.LogContext(context.Background(), utils.GetLogContextFields(ctx)...)
+		// after the transcription is stored as done or failed
+		defer releaseQuota()
+
+		// detached from the handler's cancellation, but kept in its trace
+		ctx := trace.ContextWithSpanContext(
+			withLocale(
+				utils.LogContext(context.Background(), utils.GetLogContextFields(ctx)...),
+				localeFromContext(ctx),
+			),
+			trace.SpanContextFromContext(ctx),
+		)
 
 		transcriptionCtx, cancel := context.WithTimeout(
 			ctx,
-			time.Minute*2,
+			TranscriptionTimeout,
 		)
 		defer cancel()
 
-		transcriptionErr := b.startTranscription(transcriptionCtx, attachment, e.Message, replyMessage)
+		metrics.TranscriptionsStarted.Inc()
+		transcriptionErr := b.startTranscription(transcriptionCtx, attachment, e.Message, replyMessage, member)
 		if transcriptionErr == nil {
+			metrics.TranscriptionsDone.Inc()
 			return
 		}
+		metrics.TranscriptionsFailed.Inc()
 		log.Error("failed to transcribe message", zap.Error(transcriptionErr))
 
-		if _, err := b.store.UpdateTranscriptionFailed(ctx, db.UpdateTranscriptionFailedParams{
+		if _, err := b.transcriptions.UpdateTranscriptionFailed(ctx, db.UpdateTranscriptionFailedParams{
 			GuildID:           e.Message.GuildID,
 			ChannelID:         e.Message.ChannelID,
 			OriginalMessageID: e.Message.ID,
@@ -152,8 +220,13 @@ func (b *DiscordBot) handleMessageCreateASR(ctx context.Context, e *discordgo.Me
 //
 // It is the caller's responsibility to mark the transcription as errored if
 // it fails.
-func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discordgo.MessageAttachment, callerMessage, replyMessage *discordgo.Message) error {
-	err := b.store.CreateStartedTranscription(ctx, db.CreateStartedTranscriptionParams{
+func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discordgo.MessageAttachment, callerMessage, replyMessage *discordgo.Message, author *db.User) (err error) {
+	ctx, span := tracing.Start(ctx, "transcription")
+	defer func() { tracing.End(span, err) }()
+
+	log := utils.GetLogFromContext(ctx, b.log)
+
+	err = b.transcriptions.CreateStartedTranscription(ctx, db.CreateStartedTranscriptionParams{
 		GuildID:                callerMessage.GuildID,
 		ChannelID:              callerMessage.ChannelID,
 		OriginalMessageID:      callerMessage.ID,
@@ -163,54 +236,96 @@ func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discord
 			Valid: true,
 		},
 		ResponseMessageID: replyMessage.ID,
+		AuthorID: pgtype.Text{
+			String: callerMessage.Author.ID,
+			Valid:  true,
+		},
+		// transcriptions are only triggered by the author sending a voice message
+		RequesterID: pgtype.Text{
+			String: callerMessage.Author.ID,
+			Valid:  true,
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("creating in db: %w", err)
 	}
 
-	tempfile, err := b.downloadAttachmentToTemp(ctx, attachment.URL, MaxInputFileSize)
-	if errors.Is(err, utils.ErrIOLimitReached) {
-		return fmt.Errorf("attachment too big: %w", err)
-	} else if err != nil {
+	stageStart := time.Now()
+	attachmentBody, err := b.openAttachment(ctx, attachment.URL)
+	if err != nil {
 		return DiscordExecutionError{
 			Message: "Error downloading file.",
 			Err:     fmt.Errorf("downloading attachment: %w", err),
 		}
 	}
-	defer os.Remove(tempfile)
+	defer attachmentBody.Close()
+	metrics.ObserveStage(metrics.StageDownloadResponse, time.Since(stageStart).Seconds())
 
 	start := time.Now()
 
-	duration, err := b.ffmpeg.FFprobeDurationFromFile(ctx, tempfile)
+	// the body is streamed through ffmpeg, which also reports the duration.
+	// voice messages are Ogg/Opus, so the exact duration is parsed on the way.
+	oggParser := &media.OggOpusParser{}
+	profile := asr.PreferredEncodingProfile(b.asrAPI)
+	stageStart = time.Now()
+	resampled, err := b.ffmpeg.FFmpegProbeAndResampleAudio(ctx, io.TeeReader(attachmentBody, oggParser), profile, MaxInputFileSize, MaxOutputFileSize)
 	if err != nil {
-		return fmt.Errorf("duration: %w", err)
+		return fmt.Errorf("resampling: %w", err)
+	}
+	duration := resampled.Duration
+	if oggInfo, err := oggParser.Info(); err == nil {
+		duration = oggInfo.Duration
+	} else {
+		log.Debug("couldn't parse ogg opus, using ffmpeg duration", zap.Error(err))
 	}
 	if duration > MaxDuration {
 		return fmt.Errorf("file too long: %fs", duration)
 	}
+	metrics.ObserveStage(metrics.StageFFmpeg, time.Since(stageStart).Seconds())
 
-	outputData, err := b.ffmpeg.FFmpegResampleAudioFromFile(ctx, tempfile, MaxOutputFileSize)
-	if err != nil {
-		return fmt.Errorf("resampling: %w", err)
+	// silent messages make Whisper hallucinate, so they aren't sent to the API
+	silent := resampled.Silent
+
+	transcriptionOutput := &asr.ASROutput{}
+	if silent {
+		log.Info("no speech detected")
+	} else {
+		stageStart = time.Now()
+		// chunks are split in the resampled audio, which preprocessing can
+		// make shorter than the input
+		transcriptionOutput, err = TranscribeAudio(ctx, b.ffmpeg, b.asrAPI, resampled.Data, resampled.Duration, profile)
+		if err != nil {
+			return DiscordExecutionError{
+				Message: "Error generating transcript.",
+				Err:     fmt.Errorf("generating transcript: %w", err),
+			}
+		}
+		metrics.ObserveStage(metrics.StageASR, time.Since(stageStart).Seconds())
 	}
 
-	transcriptionOutput, err := b.asrAPI.Run(ctx, outputData)
-	if err != nil {
-		return DiscordExecutionError{
-			Message: "Error generating transcript.",
-			Err:     fmt.Errorf("generating transcript: %w", err),
+	lowConfidence := asr.LowConfidence(transcriptionOutput)
+	suppress := false
+	if !silent && lowConfidence != "" {
+		settings, err := b.guildSettings.GetGuildSettingsOrDefault(ctx, callerMessage.GuildID)
+		if err != nil {
+			return fmt.Errorf("getting guild settings: %w", err)
 		}
+		suppress = settings.LowConfidenceAction == db.LowConfidenceActionSuppress
+		log.With(
+			zap.String("reason", string(lowConfidence)),
+			zap.Bool("suppressed", suppress),
+		).Info("low confidence transcript")
 	}
 
 	processingTime := time.Since(start).Seconds()
 
-	dbTranscription, err := b.store.UpdateTranscriptionDone(ctx, db.UpdateTranscriptionDoneParams{
+	dbTranscription, err := b.transcriptions.UpdateTranscriptionDone(ctx, db.UpdateTranscriptionDoneParams{
 		GuildID:           callerMessage.GuildID,
 		ChannelID:         callerMessage.ChannelID,
 		OriginalMessageID: callerMessage.ID,
 		TranscriptionModel: pgtype.Text{
 			String: transcriptionOutput.ModelName,
-			Valid:  true,
+			Valid:  transcriptionOutput.ModelName != "",
 		},
 		VoiceMessageAudioDuration: pgtype.Float8{
 			Float64: duration,
@@ -225,20 +340,44 @@ func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discord
 		return fmt.Errorf("getting transcription status from db: %w", err)
 	}
 
-	if !dbTranscription.ResponseDeleted {
-		renderedResponse, err := b.executeMessageTemplate(ctx, "asr_result",
-			MessageContext{
-				AsrResult: &MessageContextAsrResult{
-					Text:          transcriptionOutput.Text,
+	if author.HistoryEnabled && b.history.HistoryAvailable() && transcriptionOutput.Text != "" && !suppress {
+		err = b.history.StoreTranscriptText(ctx, &dbTranscription, author.ID, transcriptionOutput.Text)
+		if err != nil {
+			log.Error("failed to store transcript text", zap.Error(err))
+		}
+	}
+
+	if suppress && !dbTranscription.ResponseDeleted {
+		err = b.discord.ChannelMessageDelete(replyMessage.ChannelID, replyMessage.ID, discordgo.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("deleting suppressed reply: %w", err)
+		}
+	} else if !dbTranscription.ResponseDeleted {
+		messageName := "asr_result"
+		messageContext := MessageContext{
+			AsrResult: &MessageContextAsrResult{
+				Text:                transcriptionOutput.Text,
+				CallerMessage:       callerMessage,
+				Duration:            processingTime,
+				LowConfidenceReason: string(lowConfidence),
+			},
+		}
+		if silent {
+			messageName = "asr_no_speech"
+			messageContext = MessageContext{
+				AsrNoSpeech: &MessageContextAsrNoSpeech{
 					CallerMessage: callerMessage,
 					Duration:      processingTime,
 				},
-			},
-		)
+			}
+		}
+
+		renderedResponse, err := b.executeMessageTemplate(ctx, messageName, messageContext)
 		if err != nil {
 			return fmt.Errorf("rendering message: %w", err)
 		}
 
+		editCtx, editSpan := tracing.Start(ctx, "discord.edit_reply")
 		_, err = b.discord.ChannelMessageEditComplex(
 			&discordgo.MessageEdit{
 				Channel: replyMessage.ChannelID,
@@ -248,8 +387,9 @@ func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discord
 				Embeds:     &renderedResponse.Embeds,
 				Components: &renderedResponse.Components,
 			},
-			discordgo.WithContext(ctx),
+			discordgo.WithContext(editCtx),
 		)
+		tracing.End(editSpan, err)
 		if err != nil {
 			return fmt.Errorf("editing message: %w", err)
 		}
@@ -257,38 +397,45 @@ func (b *DiscordBot) startTranscription(ctx context.Context, attachment *discord
 	return nil
 }
 
-// downloadAttachmentToTemp downloads url into a temp file with a size limit, returning the path.
-//
-// It is the caller's responsibility to clean up the temp file.
-func (b *DiscordBot) downloadAttachmentToTemp(ctx context.Context, url string, maxSize int) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
+// TranscribeAudio runs the ASR API on resampled audio, splitting audio longer
+// than a chunk into chunks transcribed concurrently.
+func TranscribeAudio(ctx context.Context, ffmpeg *media.FFmpeg, api asr.SpeechRecognitionAPI, data []byte, duration float64, profile media.EncodingProfile) (*asr.ASROutput, error) {
+	if duration <= media.DefaultChunkOptions.MaxDuration {
+		return api.Run(ctx, data)
 	}
 
-	resp, err := b.http.Do(req)
+	chunks, err := ffmpeg.FFmpegSplitAudio(ctx, data, duration, profile, media.DefaultChunkOptions, MaxOutputFileSize)
 	if err != nil {
-		return "", fmt.Errorf("performing request: %w", err)
+		return nil, fmt.Errorf("splitting audio: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad http status: %s", resp.Status)
-	}
+	return asr.RunChunks(ctx, api, chunks, ChunkParallelism)
+}
+
+// openAttachment starts downloading url, returning the response body.
+//
+// It is the caller's responsibility to close the body and limit how much is
+// read from it.
+func (b *DiscordBot) openAttachment(ctx context.Context, url string) (body io.ReadCloser, err error) {
+	ctx, span := tracing.Start(ctx, "open_attachment")
+	defer func() { tracing.End(span, err) }()
 
-	tempFile, err := os.CreateTemp("", "orange-*")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("making temp file: %w", err)
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	defer tempFile.Close()
-	_, err = utils.CopyLimit(tempFile, resp.Body, int64(maxSize))
+	resp, err := b.http.Do(req)
 	if err != nil {
-		os.Remove(tempFile.Name())
-		return "", fmt.Errorf("writing to the temp file: %w", err)
+		return nil, fmt.Errorf("performing request: %w", err)
 	}
 
-	return tempFile.Name(), nil
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad http status: %s", resp.Status)
+	}
+
+	return resp.Body, nil
 }
 
 // sendASRNudge DMs the user a nudge about their voice message and updates
@@ -296,7 +443,7 @@ func (b *DiscordBot) downloadAttachmentToTemp(ctx context.Context, url string, m
 func (b *DiscordBot) sendASRNudge(ctx context.Context, e *discordgo.MessageCreate) error {
 	log := utils.GetLogFromContext(ctx, b.log)
 
-	err := b.store.UpdateUserASRNudge(
+	err := b.users.UpdateUserASRNudge(
 		context.Background(),
 		db.UpdateUserASRNudgeParams{
 			ID:        e.Author.ID,
@@ -342,12 +489,12 @@ func (b *DiscordBot) sendASRNudge(ctx context.Context, e *discordgo.MessageCreat
 }
 
 func (b *DiscordBot) handleMessageDeleteASR(ctx context.Context, d DeletedMessage) error {
-	transcription, err := b.store.UpdateTranscriptionMessageDeleted(ctx, db.UpdateTranscriptionMessageDeletedParams{
+	transcription, err := b.transcriptions.UpdateTranscriptionMessageDeleted(ctx, db.UpdateTranscriptionMessageDeletedParams{
 		GuildID:   d.GuildID,
 		ChannelID: d.ChannelID,
 		MessageID: d.ID,
 	})
-	if errors.Is(err, pgx.ErrNoRows) {
+	if errors.Is(err, store.ErrNotFound) {
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("updating original deleted: %w", err)
@@ -371,7 +518,7 @@ func (b *DiscordBot) handleASRToggleInteraction(ctx context.Context, id *Compone
 		return err
 	}
 
-	user, err := b.store.GetOrCreateUser(ctx, discordUser.ID)
+	user, err := b.users.GetOrCreateUser(ctx, discordUser.ID)
 	if err != nil {
 		return fmt.Errorf("getting user: %w", err)
 	}
@@ -380,7 +527,7 @@ func (b *DiscordBot) handleASRToggleInteraction(ctx context.Context, id *Compone
 	changed := user.AsrEnabled != enableASR
 
 	if changed {
-		err = b.store.UpdateUserASREnabled(ctx, db.UpdateUserASREnabledParams{
+		err = b.users.UpdateUserASREnabled(ctx, db.UpdateUserASREnabledParams{
 			ID:         discordUser.ID,
 			AsrEnabled: enableASR,
 		})
@@ -400,12 +547,9 @@ func (b *DiscordBot) handleASRToggleInteraction(ctx context.Context, id *Compone
 	}
 
 	if id.Source == ComponentSourceSettings {
+		user.AsrEnabled = enableASR
 		output, err := b.executeMessageTemplate(ctx, "user_settings", MessageContext{
-			UserSettings: &MessageContextUserSettings{
-				ASREnabled:            enableASR,
-				ASREnableComponentID:  ComponentIDString(ComponentSourceSettings, ComponentActionASREnable),
-				ASRDisableComponentID: ComponentIDString(ComponentSourceSettings, ComponentActionASRDisable),
-			},
+			UserSettings: b.userSettingsContext(user),
 		})
 		if err != nil {
 			return fmt.Errorf("rendering settings: %w", err)
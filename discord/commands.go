@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/K3das/orange/store/db"
 	"github.com/K3das/orange/utils"
 	"github.com/bwmarrin/discordgo"
 	"go.uber.org/zap"
@@ -13,16 +14,31 @@ import (
 const (
 	CommandNameCreateHook   = "create-owned-hook"
 	CommandNameUserSettings = "settings"
+	CommandNameHistory      = "history"
 )
 
 const (
 	ComponentSourceSettings = ComponentIDSource("settings")
 )
 
+// commandStringArgs are format arguments for the command strings that take
+// them.
+var commandStringArgs = map[string][]any{
+	"command.stats.option.days.description": {StatsDefaultDays},
+}
+
 func (b *DiscordBot) registerCommands(ctx context.Context) error {
+	localizer, err := b.newCommandLocalizer()
+	if err != nil {
+		return fmt.Errorf("loading command strings: %w", err)
+	}
+
 	adminPerms := int64(discordgo.PermissionAdministrator)
 	defaultPerms := int64(discordgo.PermissionViewChannel)
-	createdCommands, err := b.discord.ApplicationCommandBulkOverwrite(b.self.ID, "", []*discordgo.ApplicationCommand{
+	manageGuildPerms := int64(discordgo.PermissionManageGuild)
+	zero := float64(0)
+	one := float64(1)
+	commands := []*discordgo.ApplicationCommand{
 		{
 			Type:                     discordgo.ChatApplicationCommand,
 			Name:                     CommandNameCreateHook,
@@ -36,7 +52,91 @@ func (b *DiscordBot) registerCommands(ctx context.Context) error {
 			DefaultMemberPermissions: &defaultPerms,
 			Description:              "Configure Orange's features.",
 		},
-	}, discordgo.WithContext(ctx))
+		{
+			Type:                     discordgo.ChatApplicationCommand,
+			Name:                     CommandNameHistory,
+			DefaultMemberPermissions: &defaultPerms,
+			Description:              "Browse and search your stored transcripts.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        HistoryOptionSearch,
+					Description: "Only show transcripts matching these words.",
+					MaxLength:   HistoryMaxQueryLength,
+				},
+			},
+		},
+		{
+			Type:                     discordgo.ChatApplicationCommand,
+			Name:                     CommandNamePrivacy,
+			DefaultMemberPermissions: &defaultPerms,
+			Description:              "Export or delete the data Orange stores about you.",
+		},
+		{
+			Type:                     discordgo.ChatApplicationCommand,
+			Name:                     CommandNameStats,
+			DefaultMemberPermissions: &manageGuildPerms,
+			Description:              "Show Orange's usage statistics.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        StatsOptionScope,
+					Description: "Whose usage to show.",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "This server", Value: StatsScopeGuild},
+						{Name: "Bot-wide (operators only)", Value: StatsScopeGlobal},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        StatsOptionDays,
+					Description: localizer.englishText("command.stats.option.days.description"),
+					MinValue:    &one,
+					MaxValue:    StatsMaxDays,
+				},
+			},
+		},
+		{
+			Type:                     discordgo.ChatApplicationCommand,
+			Name:                     CommandNameGuildSettings,
+			DefaultMemberPermissions: &adminPerms,
+			Description:              "View or change Orange's settings for this server.",
+			Contexts:                 &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        GuildSettingsOptionUserDailyMinutes,
+					Description: "Minutes of audio each member can transcribe per day, 0 resets to the default.",
+					MinValue:    &zero,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        GuildSettingsOptionGuildDailyMinutes,
+					Description: "Minutes of audio the server can transcribe per day, 0 resets to the default.",
+					MinValue:    &zero,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        GuildSettingsOptionLowConfidence,
+					Description: "What to do with transcripts that look like hallucinations.",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "Flag them",
+							Value: string(db.LowConfidenceActionFlag),
+						},
+						{
+							Name:  "Don't reply",
+							Value: string(db.LowConfidenceActionSuppress),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	localizer.localize(commands)
+
+	createdCommands, err := b.discord.ApplicationCommandBulkOverwrite(b.self.ID, "", commands, discordgo.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -67,6 +167,14 @@ func (b *DiscordBot) handleCommandInteraction(ctx context.Context, e *discordgo.
 		commandErr = b.handleCommandCreateHook(ctx, e, data)
 	case CommandNameUserSettings:
 		commandErr = b.handleCommandUserSettings(ctx, e, data)
+	case CommandNameHistory:
+		commandErr = b.handleCommandHistory(ctx, e, data)
+	case CommandNamePrivacy:
+		commandErr = b.handleCommandPrivacy(ctx, e, data)
+	case CommandNameGuildSettings:
+		commandErr = b.handleCommandGuildSettings(ctx, e, data)
+	case CommandNameStats:
+		commandErr = b.handleCommandStats(ctx, e, data)
 	}
 
 	if commandErr != nil {
@@ -162,17 +270,13 @@ func (b *DiscordBot) handleCommandUserSettings(ctx context.Context, e *discordgo
 		return err
 	}
 
-	user, err := b.store.GetOrCreateUser(ctx, discordUser.ID)
+	user, err := b.users.GetOrCreateUser(ctx, discordUser.ID)
 	if err != nil {
 		return fmt.Errorf("getting user: %w", err)
 	}
 
 	output, err := b.executeMessageTemplate(ctx, "user_settings", MessageContext{
-		UserSettings: &MessageContextUserSettings{
-			ASREnabled:            user.AsrEnabled,
-			ASREnableComponentID:  ComponentIDString(ComponentSourceSettings, ComponentActionASREnable),
-			ASRDisableComponentID: ComponentIDString(ComponentSourceSettings, ComponentActionASRDisable),
-		},
+		UserSettings: b.userSettingsContext(user),
 	})
 	if err != nil {
 		return fmt.Errorf("rendering message: %w", err)
@@ -194,3 +298,16 @@ func (b *DiscordBot) handleCommandUserSettings(ctx context.Context, e *discordgo
 
 	return nil
 }
+
+func (b *DiscordBot) userSettingsContext(user *db.User) *MessageContextUserSettings {
+	return &MessageContextUserSettings{
+		ASREnabled:            user.AsrEnabled,
+		ASREnableComponentID:  ComponentIDString(ComponentSourceSettings, ComponentActionASREnable),
+		ASRDisableComponentID: ComponentIDString(ComponentSourceSettings, ComponentActionASRDisable),
+
+		HistoryAvailable:          b.history.HistoryAvailable(),
+		HistoryEnabled:            user.HistoryEnabled,
+		HistoryEnableComponentID:  ComponentIDString(ComponentSourceSettings, ComponentActionHistoryEnable),
+		HistoryDisableComponentID: ComponentIDString(ComponentSourceSettings, ComponentActionHistoryDisable),
+	}
+}
@@ -16,6 +16,10 @@ type ComponentID struct {
 
 	// Action is what the component should do, ie: "enable_asr"
 	Action ComponentIDAction
+
+	// Data is optional state for the action, ie: a page number. It may
+	// contain ":".
+	Data string
 }
 
 var (
@@ -29,8 +33,8 @@ func ParseComponentID(id string) (*ComponentID, error) {
 		return nil, ErrComponentIDInvalidPrefix
 	}
 
-	parts := strings.Split(id, ":")
-	if len(parts) != 2 {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) < 2 {
 		return nil, ErrComponentIDInvalidParts
 	}
 
@@ -38,15 +42,22 @@ func ParseComponentID(id string) (*ComponentID, error) {
 		Source: ComponentIDSource(parts[0]),
 		Action: ComponentIDAction(parts[1]),
 	}
+	if len(parts) == 3 {
+		parsedID.Data = parts[2]
+	}
 
 	return parsedID, nil
 }
 
 func (c *ComponentID) String() string {
-	return ComponentIDPrefix + strings.Join([]string{
+	parts := []string{
 		string(c.Source),
 		string(c.Action),
-	}, ":")
+	}
+	if c.Data != "" {
+		parts = append(parts, c.Data)
+	}
+	return ComponentIDPrefix + strings.Join(parts, ":")
 }
 
 func ComponentIDString(source ComponentIDSource, action ComponentIDAction) string {
@@ -56,3 +67,12 @@ func ComponentIDString(source ComponentIDSource, action ComponentIDAction) strin
 	}
 	return componentID.String()
 }
+
+func ComponentIDStringWithData(source ComponentIDSource, action ComponentIDAction, data string) string {
+	componentID := &ComponentID{
+		Source: source,
+		Action: action,
+		Data:   data,
+	}
+	return componentID.String()
+}
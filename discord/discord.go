@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/K3das/orange/asr"
 	"github.com/K3das/orange/media"
 	"github.com/K3das/orange/messages"
+	"github.com/K3das/orange/metrics"
+	"github.com/K3das/orange/ratelimit"
 	"github.com/K3das/orange/store"
 	"github.com/K3das/orange/utils"
 	"github.com/bwmarrin/discordgo"
@@ -41,8 +44,14 @@ func (e *DiscordExecutionError) Unwrap() error {
 type DiscordBot struct {
 	log *zap.Logger
 
-	discord  *discordgo.Session
-	store    *store.Store
+	discord *discordgo.Session
+
+	users          store.UserRepository
+	transcriptions store.TranscriptionRepository
+	guildSettings  store.GuildSettingsRepository
+	history        store.HistoryRepository
+	privacy        store.PrivacyRepository
+
 	messages *messages.MessageProvider
 	asrAPI   asr.SpeechRecognitionAPI
 
@@ -51,17 +60,36 @@ type DiscordBot struct {
 
 	self *discordgo.User
 
+	// set while the gateway is connected
+	ready atomic.Bool
+
 	commands   map[string]*discordgo.ApplicationCommand
 	commandsMu sync.RWMutex
 
 	knownServers map[string]struct{}
+
+	rateLimits    RateLimitOptions
+	userLimiter   *ratelimit.Limiter
+	guildLimiter  *ratelimit.Limiter
+	noticeLimiter *ratelimit.Limiter
+	reservedAudio *audioReservations
+
+	botAdmins []string
+	asrPrices map[string]float64
 }
 
 type DiscordBotOptions struct {
 	ParentLogger *zap.Logger
-	Store        *store.Store
-	Messages     *messages.MessageProvider
-	ASR          asr.SpeechRecognitionAPI
+
+	// the parts of the store the bot uses, usually all the same *store.Store
+	Users          store.UserRepository
+	Transcriptions store.TranscriptionRepository
+	GuildSettings  store.GuildSettingsRepository
+	History        store.HistoryRepository
+	Privacy        store.PrivacyRepository
+
+	Messages *messages.MessageProvider
+	ASR      asr.SpeechRecognitionAPI
 
 	Token   string
 	Servers []string
@@ -83,8 +111,13 @@ func WithHTTPClient(client *http.Client) DiscordBotOptionsExtraOptions {
 
 func NewDiscordBot(ctx context.Context, options DiscordBotOptions, extraOptions ...DiscordBotOptionsExtraOptions) (*DiscordBot, error) {
 	b := &DiscordBot{
-		log:   options.ParentLogger.Named("discord_bot"),
-		store: options.Store,
+		log: options.ParentLogger.Named("discord_bot"),
+
+		users:          options.Users,
+		transcriptions: options.Transcriptions,
+		guildSettings:  options.GuildSettings,
+		history:        options.History,
+		privacy:        options.Privacy,
 
 		messages: options.Messages,
 		asrAPI:   options.ASR,
@@ -97,6 +130,12 @@ func NewDiscordBot(ctx context.Context, options DiscordBotOptions, extraOptions
 		option(b)
 	}
 
+	b.userLimiter = ratelimit.NewLimiter(b.rateLimits.UserPerHour, b.rateLimits.UserBurst)
+	b.guildLimiter = ratelimit.NewLimiter(b.rateLimits.GuildPerHour, b.rateLimits.GuildBurst)
+	// one rate limit notice per user every 10 minutes
+	b.noticeLimiter = ratelimit.NewLimiter(6, 1)
+	b.reservedAudio = newAudioReservations()
+
 	for _, v := range options.Servers {
 		b.knownServers[v] = struct{}{}
 	}
@@ -106,7 +145,11 @@ func NewDiscordBot(ctx context.Context, options DiscordBotOptions, extraOptions
 		return nil, fmt.Errorf("creating discordgo instance: %w", err)
 	}
 	b.discord = discord
-	b.discord.Client = b.http
+
+	// copy the client so only Discord API requests are counted
+	discordClient := *b.http
+	discordClient.Transport = metrics.DiscordTransport(b.http.Transport)
+	b.discord.Client = &discordClient
 
 	state := discordgo.NewState()
 	state.TrackChannels = false
@@ -122,6 +165,8 @@ func NewDiscordBot(ctx context.Context, options DiscordBotOptions, extraOptions
 	b.discord.StateEnabled = true
 
 	b.discord.AddHandler(b.handleReady)
+	b.discord.AddHandler(b.handleResumed)
+	b.discord.AddHandler(b.handleDisconnect)
 	b.discord.AddHandler(b.handleMessageCreate)
 	b.discord.AddHandler(b.handleInteractionCreate)
 	b.discord.AddHandler(b.handleMessageDeleteEvent)
@@ -151,9 +196,25 @@ func NewDiscordBot(ctx context.Context, options DiscordBotOptions, extraOptions
 }
 
 func (b *DiscordBot) handleReady(s *discordgo.Session, e *discordgo.Ready) {
+	b.ready.Store(true)
 	b.log.Info("gateway ready")
 }
 
+func (b *DiscordBot) handleResumed(s *discordgo.Session, e *discordgo.Resumed) {
+	b.ready.Store(true)
+	b.log.Info("gateway resumed")
+}
+
+func (b *DiscordBot) handleDisconnect(s *discordgo.Session, e *discordgo.Disconnect) {
+	b.ready.Store(false)
+	b.log.Warn("gateway disconnected")
+}
+
+// Ready reports whether the gateway is connected.
+func (b *DiscordBot) Ready() bool {
+	return b.ready.Load()
+}
+
 func (b *DiscordBot) Open() error {
 	return b.discord.Open()
 }
@@ -0,0 +1,118 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const CommandNameGuildSettings = "server-settings"
+
+const (
+	GuildSettingsOptionUserDailyMinutes  = "user-daily-minutes"
+	GuildSettingsOptionGuildDailyMinutes = "server-daily-minutes"
+	GuildSettingsOptionLowConfidence     = "low-confidence"
+)
+
+func (b *DiscordBot) guildSettingsContext(settings *db.GuildSetting) *MessageContextGuildSettings {
+	return &MessageContextGuildSettings{
+		UserDailyAudioSeconds:         effectiveQuota(b.rateLimits.UserDailyAudioSeconds, settings.UserDailyAudioSeconds),
+		GuildDailyAudioSeconds:        effectiveQuota(b.rateLimits.GuildDailyAudioSeconds, settings.GuildDailyAudioSeconds),
+		DefaultUserDailyAudioSeconds:  b.rateLimits.UserDailyAudioSeconds,
+		DefaultGuildDailyAudioSeconds: b.rateLimits.GuildDailyAudioSeconds,
+		UserDailyAudioOverridden:      settings.UserDailyAudioSeconds.Valid,
+		GuildDailyAudioOverridden:     settings.GuildDailyAudioSeconds.Valid,
+		LowConfidenceAction:           string(settings.LowConfidenceAction),
+	}
+}
+
+// minutesOverride converts a minutes option to a quota override, 0 resets it
+// to the deployment default.
+func minutesOverride(minutes int64) pgtype.Float8 {
+	if minutes <= 0 {
+		return pgtype.Float8{}
+	}
+	return pgtype.Float8{
+		Float64: float64(minutes * 60),
+		Valid:   true,
+	}
+}
+
+func (b *DiscordBot) handleCommandGuildSettings(ctx context.Context, e *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) error {
+	if e.Member == nil || e.GuildID == "" {
+		return DiscordExecutionError{
+			Message:   "Run this command in a server.",
+			UserError: true,
+		}
+	}
+	if e.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		return DiscordExecutionError{
+			Message:   "You need to be administrator to run this.",
+			UserError: true,
+		}
+	}
+
+	settings, err := b.guildSettings.GetGuildSettingsOrDefault(ctx, e.GuildID)
+	if err != nil {
+		return fmt.Errorf("getting settings: %w", err)
+	}
+
+	if len(data.Options) > 0 {
+		params := db.UpsertGuildSettingsParams{
+			GuildID:                e.GuildID,
+			UserDailyAudioSeconds:  settings.UserDailyAudioSeconds,
+			GuildDailyAudioSeconds: settings.GuildDailyAudioSeconds,
+			LowConfidenceAction:    settings.LowConfidenceAction,
+			UpdatedBy: pgtype.Text{
+				String: e.Member.User.ID,
+				Valid:  true,
+			},
+		}
+
+		for _, option := range data.Options {
+			switch option.Name {
+			case GuildSettingsOptionUserDailyMinutes:
+				params.UserDailyAudioSeconds = minutesOverride(option.IntValue())
+			case GuildSettingsOptionGuildDailyMinutes:
+				params.GuildDailyAudioSeconds = minutesOverride(option.IntValue())
+			case GuildSettingsOptionLowConfidence:
+				params.LowConfidenceAction = db.LowConfidenceAction(option.StringValue())
+			}
+		}
+
+		updated, err := b.guildSettings.UpsertGuildSettings(ctx, params)
+		if err != nil {
+			return DiscordExecutionError{
+				Message: "Couldn't update server settings.",
+				Err:     fmt.Errorf("updating settings: %w", err),
+			}
+		}
+		settings = &updated
+	}
+
+	output, err := b.executeMessageTemplate(ctx, "guild_settings", MessageContext{
+		GuildSettings: b.guildSettingsContext(settings),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	return nil
+}
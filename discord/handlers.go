@@ -22,6 +22,9 @@ func (b *DiscordBot) handleMessageCreate(s *discordgo.Session, e *discordgo.Mess
 		return
 	}
 
+	// replies are seen by the whole channel, so use the server's language
+	ctx = withLocale(ctx, b.guildLocale(e.GuildID))
+
 	err := b.handleMessageCreateASR(ctx, e)
 	if err != nil {
 		log.Error("error handling message asr", zap.Error(err))
@@ -33,6 +36,8 @@ func (b *DiscordBot) handleInteractionCreate(s *discordgo.Session, e *discordgo.
 
 	defer utils.PanicRecovery(log)
 
+	ctx = withLocale(ctx, string(e.Locale))
+
 	switch e.Type {
 	case discordgo.InteractionApplicationCommand:
 		data := e.ApplicationCommandData()
@@ -0,0 +1,184 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/db"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	HistoryOptionSearch = "search"
+
+	// HistoryMaxQueryLength keeps the query short enough to fit in the
+	// pagination buttons' custom IDs (100 characters)
+	HistoryMaxQueryLength = 64
+
+	HistoryPageSize = 5
+)
+
+const (
+	ComponentActionHistoryEnable  = ComponentIDAction("history_enable")
+	ComponentActionHistoryDisable = ComponentIDAction("history_disable")
+	ComponentActionHistoryPage    = ComponentIDAction("history_page")
+
+	ComponentSourceHistory = ComponentIDSource("history")
+)
+
+var errHistoryUnavailable = DiscordExecutionError{
+	Message:   "Transcript history isn't available on this instance of Orange.",
+	UserError: true,
+}
+
+func (b *DiscordBot) handleCommandHistory(ctx context.Context, e *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) error {
+	var query string
+	for _, option := range data.Options {
+		if option.Name == HistoryOptionSearch {
+			query = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	return b.respondHistoryPage(ctx, e, discordgo.InteractionResponseChannelMessageWithSource, query, 0)
+}
+
+func (b *DiscordBot) handleHistoryPageInteraction(ctx context.Context, id *ComponentID, e *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) error {
+	rawPage, query, _ := strings.Cut(id.Data, ":")
+	page, err := strconv.Atoi(rawPage)
+	if err != nil || page < 0 {
+		return fmt.Errorf("invalid history page %q", rawPage)
+	}
+
+	return b.respondHistoryPage(ctx, e, discordgo.InteractionResponseUpdateMessage, query, page)
+}
+
+// respondHistoryPage responds to the interaction with one page of the
+// interaction user's transcript history.
+func (b *DiscordBot) respondHistoryPage(ctx context.Context, e *discordgo.InteractionCreate, responseType discordgo.InteractionResponseType, query string, page int) error {
+	if !b.history.HistoryAvailable() {
+		return errHistoryUnavailable
+	}
+
+	discordUser, err := getInteractionUser(e)
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetOrCreateUser(ctx, discordUser.ID)
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+
+	// fetch one extra entry to know if there's a next page
+	entries, err := b.history.GetTranscriptHistory(ctx, discordUser.ID, query, HistoryPageSize+1, int32(page*HistoryPageSize))
+	if errors.Is(err, store.ErrHistoryUnavailable) {
+		return errHistoryUnavailable
+	} else if err != nil {
+		return DiscordExecutionError{
+			Message: "Couldn't load your transcript history.",
+			Err:     fmt.Errorf("getting history: %w", err),
+		}
+	}
+
+	historyPage := &MessageContextHistoryPage{
+		Query:          query,
+		Page:           page,
+		HistoryEnabled: user.HistoryEnabled,
+
+		HasPrevious:         page > 0,
+		HasNext:             len(entries) > HistoryPageSize,
+		PreviousComponentID: ComponentIDStringWithData(ComponentSourceHistory, ComponentActionHistoryPage, fmt.Sprintf("%d:%s", max(page-1, 0), query)),
+		NextComponentID:     ComponentIDStringWithData(ComponentSourceHistory, ComponentActionHistoryPage, fmt.Sprintf("%d:%s", page+1, query)),
+	}
+	if historyPage.HasNext {
+		entries = entries[:HistoryPageSize]
+	}
+	for _, entry := range entries {
+		historyPage.Entries = append(historyPage.Entries, MessageContextHistoryEntry{
+			Text:       entry.Text,
+			CreatedAt:  entry.CreatedAt.Unix(),
+			MessageURL: fmt.Sprintf("https://discord.com/channels/%s/%s/%s", entry.GuildID, entry.ChannelID, entry.OriginalMessageID),
+		})
+	}
+
+	output, err := b.executeMessageTemplate(ctx, "history_page", MessageContext{
+		HistoryPage: historyPage,
+	})
+	if err != nil {
+		return fmt.Errorf("rendering history: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	return nil
+}
+
+func (b *DiscordBot) handleHistoryToggleInteraction(ctx context.Context, id *ComponentID, e *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) error {
+	if !b.history.HistoryAvailable() {
+		return errHistoryUnavailable
+	}
+
+	discordUser, err := getInteractionUser(e)
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetOrCreateUser(ctx, discordUser.ID)
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+
+	enableHistory := id.Action == ComponentActionHistoryEnable
+	if user.HistoryEnabled != enableHistory {
+		err = b.users.UpdateUserHistoryEnabled(ctx, db.UpdateUserHistoryEnabledParams{
+			ID:             discordUser.ID,
+			HistoryEnabled: enableHistory,
+		})
+		if err != nil {
+			return DiscordExecutionError{
+				Message: "Couldn't update user",
+				Err:     fmt.Errorf("updating user: %w", err),
+			}
+		}
+		user.HistoryEnabled = enableHistory
+	}
+
+	output, err := b.executeMessageTemplate(ctx, "user_settings", MessageContext{
+		UserSettings: b.userSettingsContext(user),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering settings: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("sending response: %w", err)
+	}
+
+	return nil
+}
@@ -27,6 +27,12 @@ func (b *DiscordBot) handleComponentInteraction(ctx context.Context, e *discordg
 	switch {
 	case componentID.Action == ComponentActionASREnable || componentID.Action == ComponentActionASRDisable:
 		interactionErr = b.handleASRToggleInteraction(ctx, componentID, e, data)
+	case componentID.Action == ComponentActionHistoryEnable || componentID.Action == ComponentActionHistoryDisable:
+		interactionErr = b.handleHistoryToggleInteraction(ctx, componentID, e, data)
+	case componentID.Action == ComponentActionHistoryPage:
+		interactionErr = b.handleHistoryPageInteraction(ctx, componentID, e, data)
+	case componentID.Source == ComponentSourcePrivacy:
+		interactionErr = b.handlePrivacyInteraction(ctx, componentID, e, data)
 	}
 
 	if interactionErr != nil {
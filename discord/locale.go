@@ -0,0 +1,126 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type localeContextKey struct{}
+
+// withLocale sets the locale messages rendered with ctx are localized to.
+func withLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// localeFromContext returns the locale set with withLocale, or "" for the
+// default.
+func localeFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
+
+// guildLocale returns a guild's preferred locale, or "" if it isn't cached.
+func (b *DiscordBot) guildLocale(guildID string) string {
+	if guildID == "" {
+		return ""
+	}
+	guild, err := b.discord.State.Guild(guildID)
+	if err != nil {
+		return ""
+	}
+	return guild.PreferredLocale
+}
+
+// commandLocalizer looks up translations of command strings for every locale
+// Discord supports.
+type commandLocalizer struct {
+	english map[string]string
+	tables  map[discordgo.Locale]map[string]string
+}
+
+func (b *DiscordBot) newCommandLocalizer() (*commandLocalizer, error) {
+	english, err := b.messages.Strings(string(discordgo.EnglishUS))
+	if err != nil {
+		return nil, fmt.Errorf("getting %s strings: %w", discordgo.EnglishUS, err)
+	}
+
+	l := &commandLocalizer{
+		english: english,
+		tables:  make(map[discordgo.Locale]map[string]string),
+	}
+	for locale := range discordgo.Locales {
+		if locale == discordgo.Unknown {
+			continue
+		}
+		table, err := b.messages.Strings(string(locale))
+		if err != nil {
+			return nil, fmt.Errorf("getting %s strings: %w", locale, err)
+		}
+		l.tables[locale] = table
+	}
+
+	return l, nil
+}
+
+// text looks key up in table, formatting it with its commandStringArgs.
+func (l *commandLocalizer) text(table map[string]string, key string) (string, bool) {
+	text, ok := table[key]
+	if !ok {
+		return "", false
+	}
+	if args, ok := commandStringArgs[key]; ok {
+		text = fmt.Sprintf(text, args...)
+	}
+	return text, true
+}
+
+// englishText returns the English string for key, for the command fields
+// localizations are added to.
+func (l *commandLocalizer) englishText(key string) string {
+	text, _ := l.text(l.english, key)
+	return text
+}
+
+// localizations returns the translations of key, leaving out locales that
+// fall back to English. It returns nil if there are none.
+func (l *commandLocalizer) localizations(key string) map[discordgo.Locale]string {
+	english := l.englishText(key)
+
+	var localized map[discordgo.Locale]string
+	for locale, table := range l.tables {
+		text, ok := l.text(table, key)
+		if !ok || text == english {
+			continue
+		}
+		if localized == nil {
+			localized = make(map[discordgo.Locale]string)
+		}
+		localized[locale] = text
+	}
+	return localized
+}
+
+// localize sets the name and description localizations of commands, their
+// options and choices from the "command.<name>..." strings.
+func (l *commandLocalizer) localize(commands []*discordgo.ApplicationCommand) {
+	for _, command := range commands {
+		key := "command." + command.Name
+		if names := l.localizations(key + ".name"); names != nil {
+			command.NameLocalizations = &names
+		}
+		if descriptions := l.localizations(key + ".description"); descriptions != nil {
+			command.DescriptionLocalizations = &descriptions
+		}
+
+		for _, option := range command.Options {
+			optionKey := key + ".option." + option.Name
+			option.DescriptionLocalizations = l.localizations(optionKey + ".description")
+
+			for _, choice := range option.Choices {
+				choice.NameLocalizations = l.localizations(fmt.Sprintf("%s.choice.%v", optionKey, choice.Value))
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/K3das/orange/utils"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const CommandNamePrivacy = "privacy"
+
+const (
+	ComponentActionPrivacyExport           = ComponentIDAction("privacy_export")
+	ComponentActionPrivacyDelete           = ComponentIDAction("privacy_delete")
+	ComponentActionPrivacyDeleteConfirm    = ComponentIDAction("privacy_delete_confirm")
+	ComponentActionPrivacyDeleteConfirmAll = ComponentIDAction("privacy_delete_confirm_all")
+	ComponentActionPrivacyDeleteCancel     = ComponentIDAction("privacy_delete_cancel")
+
+	ComponentSourcePrivacy = ComponentIDSource("privacy")
+)
+
+const privacyExportFileName = "orange-data.json"
+
+// the limit for deleting reply messages, which are rate limited
+const privacyReplyDeletionTimeout = time.Minute * 5
+
+func (b *DiscordBot) privacyMenuContext() *MessageContextPrivacyMenu {
+	return &MessageContextPrivacyMenu{
+		ExportComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyExport),
+		DeleteComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDelete),
+	}
+}
+
+func (b *DiscordBot) handleCommandPrivacy(ctx context.Context, e *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) error {
+	output, err := b.executeMessageTemplate(ctx, "privacy_menu", MessageContext{
+		PrivacyMenu: b.privacyMenuContext(),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	return nil
+}
+
+func (b *DiscordBot) handlePrivacyInteraction(ctx context.Context, id *ComponentID, e *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) error {
+	switch id.Action {
+	case ComponentActionPrivacyExport:
+		return b.handlePrivacyExport(ctx, e)
+	case ComponentActionPrivacyDelete:
+		return b.respondPrivacyUpdate(ctx, e, "privacy_delete_confirm", MessageContext{
+			PrivacyDeleteConfirm: &MessageContextPrivacyDeleteConfirm{
+				ConfirmComponentID:    ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteConfirm),
+				ConfirmAllComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteConfirmAll),
+				CancelComponentID:     ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteCancel),
+			},
+		})
+	case ComponentActionPrivacyDeleteCancel:
+		return b.respondPrivacyUpdate(ctx, e, "privacy_menu", MessageContext{
+			PrivacyMenu: b.privacyMenuContext(),
+		})
+	case ComponentActionPrivacyDeleteConfirm, ComponentActionPrivacyDeleteConfirmAll:
+		return b.handlePrivacyDelete(ctx, e, id.Action == ComponentActionPrivacyDeleteConfirmAll)
+	}
+
+	return nil
+}
+
+func (b *DiscordBot) respondPrivacyUpdate(ctx context.Context, e *discordgo.InteractionCreate, messageName string, messageContext MessageContext) error {
+	output, err := b.executeMessageTemplate(ctx, messageName, messageContext)
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	return nil
+}
+
+func (b *DiscordBot) handlePrivacyExport(ctx context.Context, e *discordgo.InteractionCreate) error {
+	auditLog := utils.GetLogFromContext(ctx, b.log).Named("audit")
+
+	discordUser, err := getInteractionUser(e)
+	if err != nil {
+		return err
+	}
+
+	export, err := b.privacy.ExportUserData(ctx, discordUser.ID)
+	if err != nil {
+		return DiscordExecutionError{
+			Message: "Couldn't export your data.",
+			Err:     fmt.Errorf("exporting user data: %w", err),
+		}
+	}
+
+	exportJSON, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling export: %w", err)
+	}
+
+	output, err := b.executeMessageTemplate(ctx, "privacy_export", MessageContext{
+		PrivacyExport: &MessageContextPrivacyExport{
+			Transcriptions: len(export.Transcriptions),
+			Transcripts:    len(export.Transcripts),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+			Files: []*discordgo.File{
+				{
+					Name:        privacyExportFileName,
+					ContentType: "application/json",
+					Reader:      bytes.NewReader(exportJSON),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	auditLog.With(
+		zap.String("action", "export"),
+		zap.String("user_id", discordUser.ID),
+		zap.Int("transcriptions", len(export.Transcriptions)),
+		zap.Int("transcripts", len(export.Transcripts)),
+	).Info("exported user data")
+
+	return nil
+}
+
+func (b *DiscordBot) handlePrivacyDelete(ctx context.Context, e *discordgo.InteractionCreate, deleteReplies bool) error {
+	log := utils.GetLogFromContext(ctx, b.log)
+	auditLog := log.Named("audit")
+
+	discordUser, err := getInteractionUser(e)
+	if err != nil {
+		return err
+	}
+
+	// deleting replies can take a while with rate limits
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		return fmt.Errorf("deferring response: %w", err)
+	}
+
+	deletion, err := b.privacy.DeleteUserData(ctx, discordUser.ID)
+	if err != nil {
+		log.Error("failed to delete user data", zap.Error(err))
+		return b.editPrivacyResponse(ctx, e, MessageContext{
+			PrivacyDeleteResult: &MessageContextPrivacyDeleteResult{
+				ErrorMessage: "Couldn't delete your data, nothing was deleted.",
+			},
+		})
+	}
+
+	b.reservedAudio.addDeleted(discordUser.ID, deletion)
+
+	result := &MessageContextPrivacyDeleteResult{
+		Transcriptions: len(deletion.Transcriptions),
+		Transcripts:    int(deletion.TranscriptsDeleted),
+	}
+
+	if deleteReplies {
+		deleteCtx, cancel := context.WithTimeout(ctx, privacyReplyDeletionTimeout)
+		defer cancel()
+
+		for _, transcription := range deletion.Transcriptions {
+			if transcription.ResponseDeleted {
+				continue
+			}
+
+			err = b.discord.ChannelMessageDelete(transcription.ChannelID, transcription.ResponseMessageID, discordgo.WithContext(deleteCtx))
+			if deleteCtx.Err() != nil {
+				result.ErrorMessage = "Timed out while deleting replies, some may remain."
+				break
+			} else if err != nil {
+				log.With(
+					zap.String("reply_message", fmt.Sprintf("/%s/%s/%s", transcription.GuildID, transcription.ChannelID, transcription.ResponseMessageID)),
+					zap.Error(err),
+				).Info("couldn't delete reply message")
+				result.RepliesFailed++
+				continue
+			}
+			result.RepliesDeleted++
+		}
+	}
+
+	auditLog.With(
+		zap.String("action", "delete"),
+		zap.String("user_id", discordUser.ID),
+		zap.Bool("user_deleted", deletion.UserDeleted),
+		zap.Int("transcriptions", result.Transcriptions),
+		zap.Int("transcripts", result.Transcripts),
+		zap.Int("requests_cleared", len(deletion.RequestsCleared)),
+		zap.Bool("delete_replies", deleteReplies),
+		zap.Int("replies_deleted", result.RepliesDeleted),
+		zap.Int("replies_failed", result.RepliesFailed),
+	).Info("deleted user data")
+
+	return b.editPrivacyResponse(ctx, e, MessageContext{
+		PrivacyDeleteResult: result,
+	})
+}
+
+func (b *DiscordBot) editPrivacyResponse(ctx context.Context, e *discordgo.InteractionCreate, messageContext MessageContext) error {
+	output, err := b.executeMessageTemplate(ctx, "privacy_delete_result", messageContext)
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	_, err = b.discord.InteractionResponseEdit(e.Interaction, &discordgo.WebhookEdit{
+		Content:         &output.Content,
+		Components:      &output.Components,
+		Embeds:          &output.Embeds,
+		AllowedMentions: DefaultAllowedMentions,
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("editing response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/db"
+	"github.com/K3das/orange/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// the window for daily audio quotas
+const QuotaWindow = time.Hour * 24
+
+type RateLimitOptions struct {
+	// transcriptions per hour, 0 disables the limit
+	UserPerHour  float64 `env:"USER_PER_HOUR" envDefault:"30"`
+	UserBurst    int     `env:"USER_BURST" envDefault:"5"`
+	GuildPerHour float64 `env:"GUILD_PER_HOUR" envDefault:"300"`
+	GuildBurst   int     `env:"GUILD_BURST" envDefault:"20"`
+
+	// seconds of audio per QuotaWindow, 0 disables the quota. Guild admins can
+	// lower these for their server.
+	UserDailyAudioSeconds  float64 `env:"USER_DAILY_AUDIO_SECONDS" envDefault:"3600"`
+	GuildDailyAudioSeconds float64 `env:"GUILD_DAILY_AUDIO_SECONDS" envDefault:"36000"`
+}
+
+type RateLimitReason string
+
+const (
+	RateLimitReasonUserRate   = RateLimitReason("user_rate")
+	RateLimitReasonGuildRate  = RateLimitReason("guild_rate")
+	RateLimitReasonUserQuota  = RateLimitReason("user_quota")
+	RateLimitReasonGuildQuota = RateLimitReason("guild_quota")
+)
+
+type rateLimitResult struct {
+	Reason RateLimitReason
+	// RetryAfter is zero if unknown
+	RetryAfter time.Duration
+}
+
+func WithRateLimits(options RateLimitOptions) DiscordBotOptionsExtraOptions {
+	return func(b *DiscordBot) {
+		b.rateLimits = options
+	}
+}
+
+// effectiveQuota applies a guild's override to the deployment quota, guilds
+// can only lower it. 0 is unlimited.
+func effectiveQuota(deployment float64, override pgtype.Float8) float64 {
+	if !override.Valid {
+		return deployment
+	}
+	if deployment <= 0 {
+		return override.Float64
+	}
+	return math.Min(deployment, override.Float64)
+}
+
+// audioReservations is audio of voice messages that passed the quota checks
+// but haven't finished transcribing, so it isn't in the stored totals yet,
+// and audio of transcriptions deleted with /privacy, which isn't anymore.
+type audioReservations struct {
+	mu     sync.Mutex
+	users  map[string]float64
+	guilds map[string]float64
+
+	deleted []deletedAudio
+}
+
+// deletedAudio keeps a deleted transcription counting against quotas until
+// it leaves the window, so deleting your data doesn't reset them. It's only
+// kept in memory, like the rate limits.
+type deletedAudio struct {
+	// userID is the requester, or empty if it wasn't the deleted user
+	userID string
+	// guildID is empty if the transcription is still in the guild's totals
+	guildID   string
+	timestamp time.Time
+	duration  float64
+}
+
+func newAudioReservations() *audioReservations {
+	return &audioReservations{
+		users:  make(map[string]float64),
+		guilds: make(map[string]float64),
+	}
+}
+
+// reserve adds duration to the user's and guild's reserved audio, returning
+// a function that releases it. r.mu must be held.
+func (r *audioReservations) reserve(userID, guildID string, duration float64) func() {
+	r.users[userID] += duration
+	r.guilds[guildID] += duration
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			release(r.users, userID, duration)
+			release(r.guilds, guildID, duration)
+		})
+	}
+}
+
+// addDeleted counts the transcriptions deleted with the user's data against
+// quotas until they leave the window.
+func (r *audioReservations) addDeleted(userID string, deletion *store.UserDataDeletion) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := time.Now().Add(-QuotaWindow)
+	add := func(transcription db.AsrTranscription, requester, guildID string) {
+		if !transcription.VoiceMessageAudioDuration.Valid || transcription.OriginalMessageTimestamp.Time.Before(since) {
+			return
+		}
+		r.deleted = append(r.deleted, deletedAudio{
+			userID:    requester,
+			guildID:   guildID,
+			timestamp: transcription.OriginalMessageTimestamp.Time,
+			duration:  transcription.VoiceMessageAudioDuration.Float64,
+		})
+	}
+
+	for _, transcription := range deletion.Transcriptions {
+		requester := ""
+		if transcription.RequesterID.Valid && transcription.RequesterID.String == userID {
+			requester = userID
+		}
+		add(transcription, requester, transcription.GuildID)
+	}
+	for _, transcription := range deletion.RequestsCleared {
+		add(transcription, userID, "")
+	}
+}
+
+// deletedSince sums the user's and guild's deleted audio from since on,
+// dropping older entries. r.mu must be held.
+func (r *audioReservations) deletedSince(userID, guildID string, since time.Time) (user, guild float64) {
+	r.deleted = slices.DeleteFunc(r.deleted, func(d deletedAudio) bool {
+		return d.timestamp.Before(since)
+	})
+
+	for _, d := range r.deleted {
+		if d.userID != "" && d.userID == userID {
+			user += d.duration
+		}
+		if d.guildID != "" && d.guildID == guildID {
+			guild += d.duration
+		}
+	}
+	return user, guild
+}
+
+func release(reserved map[string]float64, key string, duration float64) {
+	reserved[key] -= duration
+	// float sums don't always come back to exactly 0
+	if reserved[key] < 1e-9 {
+		delete(reserved, key)
+	}
+}
+
+// checkTranscriptionLimits checks the author's and guild's quotas and rate
+// limits for a voice message of the given duration, returning nil if it can
+// be transcribed. Allowed messages reserve their duration against the quotas
+// until the returned release function is called, which should be once the
+// transcription is stored as done or failed.
+func (b *DiscordBot) checkTranscriptionLimits(ctx context.Context, e *discordgo.MessageCreate, duration float64) (*rateLimitResult, func(), error) {
+	settings, err := b.guildSettings.GetGuildSettingsOrDefault(ctx, e.GuildID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	since := pgtype.Timestamptz{
+		Time:  time.Now().Add(-QuotaWindow),
+		Valid: true,
+	}
+
+	userQuota := effectiveQuota(b.rateLimits.UserDailyAudioSeconds, settings.UserDailyAudioSeconds)
+	var userAudio float64
+	if userQuota > 0 {
+		totals, err := b.transcriptions.GetRequesterTranscriptionTotals(ctx, db.GetRequesterTranscriptionTotalsParams{
+			RequesterID: e.Author.ID,
+			Since:       since,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting user totals: %w", err)
+		}
+		userAudio = totals.AudioDuration
+	}
+
+	guildQuota := effectiveQuota(b.rateLimits.GuildDailyAudioSeconds, settings.GuildDailyAudioSeconds)
+	var guildAudio float64
+	if guildQuota > 0 {
+		totals, err := b.transcriptions.GetGuildTranscriptionTotals(ctx, db.GetGuildTranscriptionTotalsParams{
+			GuildID: e.GuildID,
+			Since:   since,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting guild totals: %w", err)
+		}
+		guildAudio = totals.AudioDuration
+	}
+
+	// held from checking the reservations until this one is made, so
+	// concurrent voice messages can't all fit in the same remaining quota.
+	// The totals are queried before taking it so messages don't wait on each
+	// other's queries.
+	b.reservedAudio.mu.Lock()
+	defer b.reservedAudio.mu.Unlock()
+
+	deletedUser, deletedGuild := b.reservedAudio.deletedSince(e.Author.ID, e.GuildID, since.Time)
+	userAudio += deletedUser + b.reservedAudio.users[e.Author.ID]
+	guildAudio += deletedGuild + b.reservedAudio.guilds[e.GuildID]
+
+	if userQuota > 0 && userAudio+duration > userQuota {
+		return &rateLimitResult{Reason: RateLimitReasonUserQuota}, nil, nil
+	}
+	if guildQuota > 0 && guildAudio+duration > guildQuota {
+		return &rateLimitResult{Reason: RateLimitReasonGuildQuota}, nil, nil
+	}
+
+	if ok, retryAfter := b.userLimiter.Allow(e.Author.ID); !ok {
+		return &rateLimitResult{Reason: RateLimitReasonUserRate, RetryAfter: retryAfter}, nil, nil
+	}
+	if ok, retryAfter := b.guildLimiter.Allow(e.GuildID); !ok {
+		// the message isn't transcribed, so it doesn't count against the user
+		b.userLimiter.Return(e.Author.ID)
+		return &rateLimitResult{Reason: RateLimitReasonGuildRate, RetryAfter: retryAfter}, nil, nil
+	}
+
+	return nil, b.reservedAudio.reserve(e.Author.ID, e.GuildID, duration), nil
+}
+
+// sendRateLimitNotice replies to the voice message explaining why it wasn't
+// transcribed, at most once per noticeLimiter period per user.
+func (b *DiscordBot) sendRateLimitNotice(ctx context.Context, e *discordgo.MessageCreate, result *rateLimitResult) error {
+	log := utils.GetLogFromContext(ctx, b.log)
+
+	if ok, _ := b.noticeLimiter.Allow(e.Author.ID); !ok {
+		log.Debug("not sending rate limit notice, already sent recently")
+		return nil
+	}
+
+	limited := &MessageContextAsrRateLimited{
+		Reason: string(result.Reason),
+	}
+	if result.RetryAfter > 0 {
+		limited.RetryAt = time.Now().Add(result.RetryAfter).Unix()
+	}
+
+	rendered, err := b.executeMessageTemplate(ctx, "asr_rate_limited", MessageContext{
+		AsrRateLimited: limited,
+	})
+	if err != nil {
+		return fmt.Errorf("rendering notice: %w", err)
+	}
+
+	_, err = b.discord.ChannelMessageSendComplex(e.ChannelID, &discordgo.MessageSend{
+		Content:         rendered.Content,
+		Embeds:          rendered.Embeds,
+		Components:      rendered.Components,
+		Reference:       e.Reference(),
+		AllowedMentions: DefaultAllowedMentions,
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("sending notice: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func deletedTranscription(guildID, requesterID string, age time.Duration, duration float64) db.AsrTranscription {
+	return db.AsrTranscription{
+		GuildID:                   guildID,
+		RequesterID:               pgtype.Text{String: requesterID, Valid: requesterID != ""},
+		OriginalMessageTimestamp:  pgtype.Timestamptz{Time: time.Now().Add(-age), Valid: true},
+		VoiceMessageAudioDuration: pgtype.Float8{Float64: duration, Valid: true},
+	}
+}
+
+func TestAudioReservationsDeleted(t *testing.T) {
+	r := newAudioReservations()
+	r.addDeleted("user", &store.UserDataDeletion{
+		Transcriptions: []db.AsrTranscription{
+			// the user's own request
+			deletedTranscription("guild", "user", time.Hour, 10),
+			// requested by someone else, only the guild's quota
+			deletedTranscription("guild", "other", time.Hour, 20),
+			// already outside the window
+			deletedTranscription("guild", "user", QuotaWindow+time.Hour, 40),
+		},
+		// still in the guild's totals, only the user's quota
+		RequestsCleared: []db.AsrTranscription{
+			deletedTranscription("guild", "", 2*time.Hour, 80),
+		},
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, guild := r.deletedSince("user", "guild", time.Now().Add(-QuotaWindow))
+	if user != 90 || guild != 30 {
+		t.Errorf("deleted audio is %g for the user and %g for the guild, want 90 and 30", user, guild)
+	}
+
+	if user, guild := r.deletedSince("other", "other-guild", time.Now().Add(-QuotaWindow)); user != 0 || guild != 0 {
+		t.Errorf("deleted audio counted for others: %g, %g", user, guild)
+	}
+
+	// entries are dropped once they leave the window
+	user, guild = r.deletedSince("user", "guild", time.Now().Add(-90*time.Minute))
+	if user != 10 || guild != 30 {
+		t.Errorf("deleted audio in a shorter window is %g and %g, want 10 and 30", user, guild)
+	}
+	if len(r.deleted) != 2 {
+		t.Errorf("kept %d deleted entries, want 2", len(r.deleted))
+	}
+}
@@ -0,0 +1,147 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/K3das/orange/store"
+	"github.com/bwmarrin/discordgo"
+)
+
+const CommandNameStats = "stats"
+
+const (
+	StatsOptionScope = "scope"
+	StatsOptionDays  = "days"
+
+	StatsScopeGuild  = "server"
+	StatsScopeGlobal = "global"
+
+	StatsDefaultDays = 30
+	StatsMaxDays     = 90
+
+	// the number of days listed in the embed
+	statsDayRows = 14
+)
+
+func WithBotAdmins(userIDs []string) DiscordBotOptionsExtraOptions {
+	return func(b *DiscordBot) {
+		b.botAdmins = userIDs
+	}
+}
+
+// WithASRPrices sets the price per minute of audio for each model, used to
+// estimate costs in stats.
+func WithASRPrices(pricesPerMinute map[string]float64) DiscordBotOptionsExtraOptions {
+	return func(b *DiscordBot) {
+		b.asrPrices = pricesPerMinute
+	}
+}
+
+func (b *DiscordBot) isBotAdmin(userID string) bool {
+	return slices.Contains(b.botAdmins, userID)
+}
+
+func statsRowContext(name string, row store.UsageStatsRow) MessageContextStatsRow {
+	return MessageContextStatsRow{
+		Name:              name,
+		Transcriptions:    row.Transcriptions,
+		Failed:            row.Failed,
+		AudioMinutes:      row.AudioDuration / 60,
+		ProcessingTimeP50: row.ProcessingTimeP50,
+		ProcessingTimeP95: row.ProcessingTimeP95,
+		EstimatedCost:     row.EstimatedCost,
+	}
+}
+
+func (b *DiscordBot) handleCommandStats(ctx context.Context, e *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) error {
+	discordUser, err := getInteractionUser(e)
+	if err != nil {
+		return err
+	}
+
+	scope := StatsScopeGuild
+	days := StatsDefaultDays
+	for _, option := range data.Options {
+		switch option.Name {
+		case StatsOptionScope:
+			scope = option.StringValue()
+		case StatsOptionDays:
+			days = int(option.IntValue())
+		}
+	}
+
+	global := scope == StatsScopeGlobal
+	if global && !b.isBotAdmin(discordUser.ID) {
+		return DiscordExecutionError{
+			Message:   "Only Orange's operators can see bot-wide stats.",
+			UserError: true,
+		}
+	}
+	if !global && e.GuildID == "" {
+		return DiscordExecutionError{
+			Message:   "Run this command in a server.",
+			UserError: true,
+		}
+	}
+
+	guildID := e.GuildID
+	if global {
+		guildID = ""
+	}
+
+	stats, err := b.transcriptions.GetUsageStats(ctx, guildID, time.Now().AddDate(0, 0, -days), b.asrPrices)
+	if err != nil {
+		return DiscordExecutionError{
+			Message: "Couldn't load stats.",
+			Err:     fmt.Errorf("getting stats: %w", err),
+		}
+	}
+
+	statsContext := &MessageContextStats{
+		Global: global,
+		Days:   days,
+		Total:  statsRowContext("", stats.Total),
+	}
+	if guild, err := b.discord.State.Guild(e.GuildID); err == nil {
+		statsContext.GuildName = guild.Name
+	}
+	for _, row := range stats.ByModel {
+		statsContext.ByModel = append(statsContext.ByModel, statsRowContext(row.Key, row))
+	}
+	for _, row := range stats.ByDay[:min(len(stats.ByDay), statsDayRows)] {
+		statsContext.ByDay = append(statsContext.ByDay, statsRowContext(row.Key, row))
+	}
+	for _, row := range stats.ByGuild {
+		name := row.Key
+		if guild, err := b.discord.State.Guild(row.Key); err == nil {
+			name = guild.Name
+		}
+		statsContext.ByGuild = append(statsContext.ByGuild, statsRowContext(name, row))
+	}
+
+	output, err := b.executeMessageTemplate(ctx, "stats", MessageContext{
+		Stats: statsContext,
+	})
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	err = b.discord.InteractionRespond(e.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         output.Content,
+			Components:      output.Components,
+			Embeds:          output.Embeds,
+			AllowedMentions: DefaultAllowedMentions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("responding: %w", err)
+	}
+
+	return nil
+}
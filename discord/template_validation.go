@@ -0,0 +1,388 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/K3das/orange/asr"
+	"github.com/K3das/orange/messages"
+	"github.com/K3das/orange/store/db"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's message limits, in characters
+// https://discord.com/developers/docs/resources/message#create-message-jsonjson-params
+const (
+	maxContentLength          = 2000
+	maxEmbeds                 = 10
+	maxEmbedTotalLength       = 6000
+	maxEmbedTitleLength       = 256
+	maxEmbedDescriptionLength = 4096
+	maxEmbedFields            = 25
+	maxEmbedFieldNameLength   = 256
+	maxEmbedFieldValueLength  = 1024
+	maxEmbedFooterLength      = 2048
+	maxEmbedAuthorLength      = 256
+	maxActionRows             = 5
+	maxActionRowComponents    = 5
+	maxButtonLabelLength      = 80
+	maxCustomIDLength         = 100
+)
+
+// ValidateTemplates renders every message against fixtures in every locale,
+// checking the output decodes and fits in Discord's limits. Every message in
+// the templates needs fixtures, so a new message can't skip validation.
+func ValidateTemplates(r messages.Renderer) error {
+	keys, err := r.MessageKeys()
+	if err != nil {
+		return fmt.Errorf("listing messages: %w", err)
+	}
+	locales, err := r.Locales()
+	if err != nil {
+		return fmt.Errorf("listing locales: %w", err)
+	}
+
+	fixtures := TemplateFixtures()
+	var errs []error
+	for _, key := range keys {
+		contexts, ok := fixtures[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: no fixtures", key))
+			continue
+		}
+
+		for _, locale := range locales {
+			for i, data := range contexts {
+				err := validateTemplate(r, key, fixtureContext(data, locale))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s (%s, fixture %d): %w", key, locale, i, err))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fixtureContext fills in a fixture's locale and the fields the bot sets on
+// every context, with a fixed timestamp so renders are reproducible.
+func fixtureContext(data MessageContext, locale string) MessageContext {
+	data.Locale = locale
+	data.Timestamp = time.Unix(0, 0).UTC().Format(time.RFC3339)
+	data.RegisteredCommands = FixtureCommands
+	return data
+}
+
+func validateTemplate(r messages.Renderer, messageName string, data MessageContext) error {
+	jsonOut, err := r.ExecuteMessage(messageName, data)
+	if err != nil {
+		return err
+	}
+
+	output, err := DecodeMessageOutput(jsonOut)
+	if err != nil {
+		return err
+	}
+
+	return checkMessageLimits(output)
+}
+
+// checkMessageLimits returns why Discord would reject a message, if it would.
+func checkMessageLimits(output *MessageOutput) error {
+	if output.Content == "" && len(output.Embeds) == 0 && len(output.Components) == 0 {
+		return errors.New("message is empty")
+	}
+
+	var errs []error
+	checkLength := func(name, value string, limit int) int {
+		length := utf8.RuneCountInString(value)
+		if length > limit {
+			errs = append(errs, fmt.Errorf("%s is %d characters, over %d", name, length, limit))
+		}
+		return length
+	}
+
+	checkLength("content", output.Content, maxContentLength)
+
+	if len(output.Embeds) > maxEmbeds {
+		errs = append(errs, fmt.Errorf("%d embeds, over %d", len(output.Embeds), maxEmbeds))
+	}
+	embedsLength := 0
+	for i, embed := range output.Embeds {
+		if embed == nil {
+			errs = append(errs, fmt.Errorf("embed %d is null", i))
+			continue
+		}
+		prefix := fmt.Sprintf("embed %d ", i)
+		embedsLength += checkLength(prefix+"title", embed.Title, maxEmbedTitleLength)
+		embedsLength += checkLength(prefix+"description", embed.Description, maxEmbedDescriptionLength)
+		if embed.Footer != nil {
+			embedsLength += checkLength(prefix+"footer", embed.Footer.Text, maxEmbedFooterLength)
+		}
+		if embed.Author != nil {
+			embedsLength += checkLength(prefix+"author", embed.Author.Name, maxEmbedAuthorLength)
+		}
+		if len(embed.Fields) > maxEmbedFields {
+			errs = append(errs, fmt.Errorf("embed %d has %d fields, over %d", i, len(embed.Fields), maxEmbedFields))
+		}
+		for j, field := range embed.Fields {
+			embedsLength += checkLength(fmt.Sprintf("%sfield %d name", prefix, j), field.Name, maxEmbedFieldNameLength)
+			embedsLength += checkLength(fmt.Sprintf("%sfield %d value", prefix, j), field.Value, maxEmbedFieldValueLength)
+		}
+	}
+	if embedsLength > maxEmbedTotalLength {
+		errs = append(errs, fmt.Errorf("embeds are %d characters, over %d", embedsLength, maxEmbedTotalLength))
+	}
+
+	if len(output.Components) > maxActionRows {
+		errs = append(errs, fmt.Errorf("%d action rows, over %d", len(output.Components), maxActionRows))
+	}
+	for i, component := range output.Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			errs = append(errs, fmt.Errorf("component %d isn't an action row", i))
+			continue
+		}
+		if len(row.Components) > maxActionRowComponents {
+			errs = append(errs, fmt.Errorf("action row %d has %d components, over %d", i, len(row.Components), maxActionRowComponents))
+		}
+		for j, component := range row.Components {
+			prefix := fmt.Sprintf("action row %d component %d ", i, j)
+			switch component := component.(type) {
+			case *discordgo.Button:
+				checkLength(prefix+"label", component.Label, maxButtonLabelLength)
+				checkLength(prefix+"custom_id", component.CustomID, maxCustomIDLength)
+			case *discordgo.SelectMenu:
+				checkLength(prefix+"custom_id", component.CustomID, maxCustomIDLength)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FixtureCommands stands in for the registered commands when rendering
+// without a bot.
+var FixtureCommands = map[string]*discordgo.ApplicationCommand{
+	CommandNameCreateHook:    {ID: "1370000000000000001", Name: CommandNameCreateHook},
+	CommandNameUserSettings:  {ID: "1370000000000000002", Name: CommandNameUserSettings},
+	CommandNameHistory:       {ID: "1370000000000000003", Name: CommandNameHistory},
+	CommandNamePrivacy:       {ID: "1370000000000000004", Name: CommandNamePrivacy},
+	CommandNameStats:         {ID: "1370000000000000005", Name: CommandNameStats},
+	CommandNameGuildSettings: {ID: "1370000000000000006", Name: CommandNameGuildSettings},
+}
+
+// fixtureMessage is a voice message as received from the gateway, with
+// everything message_author looks at
+func fixtureMessage(nick, avatar string) *discordgo.Message {
+	var message *discordgo.Message
+	// round trip through JSON so the fixture looks like a real event, with
+	// nested objects set
+	_ = json.Unmarshal([]byte(fmt.Sprintf(`{
+		"id": "1380000000000000001",
+		"channel_id": "1380000000000000002",
+		"guild_id": "1380000000000000003",
+		"author": {"id": "1380000000000000004", "username": "orange_user", "global_name": "Orange User", "avatar": %q},
+		"member": {"nick": %q, "avatar": ""}
+	}`, avatar, nick)), &message)
+	return message
+}
+
+func fixtureStatsRow(name string) MessageContextStatsRow {
+	return MessageContextStatsRow{
+		Name:              name,
+		Transcriptions:    12345,
+		Failed:            123,
+		AudioMinutes:      4567.8,
+		ProcessingTimeP50: 1.23,
+		ProcessingTimeP95: 4.56,
+		EstimatedCost:     12.34,
+	}
+}
+
+// TemplateFixtures returns representative contexts for every message,
+// covering their branches and the largest values they normally get. They're
+// also examples to render templates with locally.
+func TemplateFixtures() map[string][]MessageContext {
+	transcript := strings.Repeat("This is a fairly long voice message about what we should do this weekend. ", 20)
+
+	userSettings := func(asrEnabled, historyAvailable, historyEnabled bool) MessageContext {
+		return MessageContext{
+			UserSettings: &MessageContextUserSettings{
+				ASREnabled:                asrEnabled,
+				ASREnableComponentID:      ComponentIDString(ComponentSourceSettings, ComponentActionASREnable),
+				ASRDisableComponentID:     ComponentIDString(ComponentSourceSettings, ComponentActionASRDisable),
+				HistoryAvailable:          historyAvailable,
+				HistoryEnabled:            historyEnabled,
+				HistoryEnableComponentID:  ComponentIDString(ComponentSourceSettings, ComponentActionHistoryEnable),
+				HistoryDisableComponentID: ComponentIDString(ComponentSourceSettings, ComponentActionHistoryDisable),
+			},
+		}
+	}
+
+	historyEntries := make([]MessageContextHistoryEntry, HistoryPageSize)
+	for i := range historyEntries {
+		historyEntries[i] = MessageContextHistoryEntry{
+			Text:       transcript,
+			CreatedAt:  1747000000,
+			MessageURL: "https://discord.com/channels/1380000000000000003/1380000000000000002/1380000000000000001",
+		}
+	}
+	historyPage := func(query string, entries []MessageContextHistoryEntry, historyEnabled, hasPrevious, hasNext bool) MessageContext {
+		return MessageContext{
+			HistoryPage: &MessageContextHistoryPage{
+				Entries:             entries,
+				Query:               query,
+				Page:                1,
+				HistoryEnabled:      historyEnabled,
+				HasPrevious:         hasPrevious,
+				HasNext:             hasNext,
+				PreviousComponentID: ComponentIDStringWithData(ComponentSourceHistory, ComponentActionHistoryPage, "0:"+query),
+				NextComponentID:     ComponentIDStringWithData(ComponentSourceHistory, ComponentActionHistoryPage, "2:"+query),
+			},
+		}
+	}
+	longQuery := strings.Repeat("q", HistoryMaxQueryLength)
+
+	stats := func(global bool) MessageContext {
+		s := &MessageContextStats{
+			Global:    global,
+			GuildName: "A Server With A Reasonably Long Name",
+			Days:      StatsMaxDays,
+			Total:     fixtureStatsRow(""),
+			ByModel:   []MessageContextStatsRow{fixtureStatsRow("workers_whisper-@cf/openai/whisper-large-v3-turbo"), fixtureStatsRow("")},
+		}
+		for range statsDayRows {
+			s.ByDay = append(s.ByDay, fixtureStatsRow("2025-05-11"))
+		}
+		if global {
+			for range 10 {
+				s.ByGuild = append(s.ByGuild, fixtureStatsRow("A Server With A Name"))
+			}
+		}
+		return MessageContext{Stats: s}
+	}
+
+	guildSettings := func(overridden bool, action db.LowConfidenceAction) MessageContext {
+		return MessageContext{
+			GuildSettings: &MessageContextGuildSettings{
+				UserDailyAudioSeconds:         600,
+				GuildDailyAudioSeconds:        0,
+				DefaultUserDailyAudioSeconds:  1800,
+				DefaultGuildDailyAudioSeconds: 0,
+				UserDailyAudioOverridden:      overridden,
+				GuildDailyAudioOverridden:     overridden,
+				LowConfidenceAction:           string(action),
+			},
+		}
+	}
+
+	rateLimited := func(reason RateLimitReason, retryAt int64) MessageContext {
+		return MessageContext{
+			AsrRateLimited: &MessageContextAsrRateLimited{
+				Reason:  string(reason),
+				RetryAt: retryAt,
+			},
+		}
+	}
+
+	deleteResult := func(result MessageContextPrivacyDeleteResult) MessageContext {
+		return MessageContext{PrivacyDeleteResult: &result}
+	}
+
+	return map[string][]MessageContext{
+		"user_settings": {
+			userSettings(false, false, false),
+			userSettings(true, true, false),
+			userSettings(true, true, true),
+		},
+		"user_settings_toggle_response": {
+			{UserSettingsToggleResponse: &MessageContextUserSettingsToggleResponse{Enabled: true, Changed: true, Setting: "asr"}},
+			{UserSettingsToggleResponse: &MessageContextUserSettingsToggleResponse{Enabled: false, Changed: true, Setting: "asr"}},
+			{UserSettingsToggleResponse: &MessageContextUserSettingsToggleResponse{Enabled: true, Changed: false, Setting: "history"}},
+		},
+		"interaction_error": {
+			{InteractionError: &MessageContextInteractionError{Message: "Unknown error occurred."}},
+		},
+		"command_error": {
+			{CommandError: &MessageContextCommandError{Message: "Unknown error occurred."}},
+		},
+		"command_create_hook_response": {
+			{CommandCreateHookResponse: &MessageContextCommandCreateHookResponse{Hook: &discordgo.Webhook{
+				ID:    "1380000000000000005",
+				Token: strings.Repeat("t", 68),
+			}}},
+		},
+		"asr_progress": {
+			{AsrProgress: &MessageContextAsrProgress{}},
+		},
+		"asr_result": {
+			{AsrResult: &MessageContextAsrResult{Text: transcript, CallerMessage: fixtureMessage("", ""), Duration: 1.23}},
+			{AsrResult: &MessageContextAsrResult{
+				Text:                "Thanks for watching!",
+				CallerMessage:       fixtureMessage("Nickname", "a_0123456789abcdef"),
+				Duration:            0.5,
+				LowConfidenceReason: string(asr.LowConfidenceHallucination),
+			}},
+		},
+		"asr_no_speech": {
+			{AsrNoSpeech: &MessageContextAsrNoSpeech{CallerMessage: fixtureMessage("", ""), Duration: 0.2}},
+		},
+		"asr_nudge": {
+			{AsrNudge: &MessageContextAsrNudge{
+				ASREnableComponentID: ComponentIDString(ComponentSourceNudge, ComponentActionASREnable),
+				Guild:                &discordgo.Guild{ID: "1380000000000000003", Name: strings.Repeat("n", 100)},
+				ChannelID:            "1380000000000000002",
+			}},
+		},
+		"asr_rate_limited": {
+			rateLimited(RateLimitReasonUserRate, 1747000000),
+			rateLimited(RateLimitReasonGuildRate, 0),
+			rateLimited(RateLimitReasonUserQuota, 1747000000),
+			rateLimited(RateLimitReasonGuildQuota, 0),
+		},
+		"asr_error": {
+			{AsrError: &MessageContextAsrError{Message: "Voice message is too long."}},
+		},
+		"history_page": {
+			historyPage("", historyEntries, true, true, true),
+			historyPage(longQuery, historyEntries[:1], true, false, false),
+			historyPage(longQuery, nil, true, false, false),
+			historyPage("", nil, false, false, false),
+			historyPage("", nil, true, false, false),
+		},
+		"privacy_menu": {
+			{PrivacyMenu: &MessageContextPrivacyMenu{
+				ExportComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyExport),
+				DeleteComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDelete),
+			}},
+		},
+		"privacy_delete_confirm": {
+			{PrivacyDeleteConfirm: &MessageContextPrivacyDeleteConfirm{
+				ConfirmComponentID:    ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteConfirm),
+				ConfirmAllComponentID: ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteConfirmAll),
+				CancelComponentID:     ComponentIDString(ComponentSourcePrivacy, ComponentActionPrivacyDeleteCancel),
+			}},
+		},
+		"privacy_export": {
+			{PrivacyExport: &MessageContextPrivacyExport{Transcriptions: 1234, Transcripts: 567}},
+		},
+		"privacy_delete_result": {
+			deleteResult(MessageContextPrivacyDeleteResult{Transcriptions: 1234, Transcripts: 567}),
+			deleteResult(MessageContextPrivacyDeleteResult{Transcriptions: 1234, Transcripts: 567, RepliesDeleted: 1200, RepliesFailed: 34, ErrorMessage: "Stopped deleting replies after too many errors."}),
+			deleteResult(MessageContextPrivacyDeleteResult{ErrorMessage: "Couldn't delete your data."}),
+		},
+		"guild_settings": {
+			guildSettings(false, db.LowConfidenceActionFlag),
+			guildSettings(true, db.LowConfidenceActionSuppress),
+		},
+		"stats": {
+			stats(false),
+			stats(true),
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/K3das/orange/messages"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+const goldenDir = "testdata/golden"
+
+func newTestMessageProvider(t *testing.T) *messages.MessageProvider {
+	t.Helper()
+	m, err := messages.NewMessageProvider()
+	if err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+	return m
+}
+
+func TestValidateTemplates(t *testing.T) {
+	if err := ValidateTemplates(newTestMessageProvider(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTemplatesGolden renders every message with its fixtures in every locale
+// and compares them to testdata/golden/<message>.json. Run with -update after
+// changing templates or fixtures, and review the diff.
+func TestTemplatesGolden(t *testing.T) {
+	m := newTestMessageProvider(t)
+
+	keys, err := m.MessageKeys()
+	if err != nil {
+		t.Fatalf("listing messages: %v", err)
+	}
+	locales, err := m.Locales()
+	if err != nil {
+		t.Fatalf("listing locales: %v", err)
+	}
+	fixtures := TemplateFixtures()
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			// locale to the renders of each fixture
+			rendered := map[string][]json.RawMessage{}
+			for _, locale := range locales {
+				for i, data := range fixtures[key] {
+					jsonOut, err := m.ExecuteMessage(key, fixtureContext(data, locale))
+					if err != nil {
+						t.Fatalf("rendering fixture %d in %s: %v", i, locale, err)
+					}
+					rendered[locale] = append(rendered[locale], json.RawMessage(jsonOut))
+				}
+			}
+
+			// unescaped so the golden files read like the messages
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(rendered); err != nil {
+				t.Fatalf("marshaling renders: %v", err)
+			}
+			got := buf.Bytes()
+
+			path := filepath.Join(goldenDir, key+".json")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading golden file, run with -update to create it: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s doesn't match the golden file, run with -update if the change is intended:\n%s", key, got)
+			}
+		})
+	}
+
+	// golden files of removed messages
+	entries, err := os.ReadDir(goldenDir)
+	if err != nil {
+		t.Fatalf("listing golden files: %v", err)
+	}
+	for _, entry := range entries {
+		key := strings.TrimSuffix(entry.Name(), ".json")
+		found := false
+		for _, k := range keys {
+			found = found || k == key
+		}
+		if found {
+			continue
+		}
+		if *update {
+			os.Remove(filepath.Join(goldenDir, entry.Name()))
+			continue
+		}
+		t.Errorf("golden file %s has no message", entry.Name())
+	}
+}
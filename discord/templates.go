@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/K3das/orange/metrics"
 	"github.com/K3das/orange/utils"
 	"github.com/bwmarrin/discordgo"
 	"go.uber.org/zap"
@@ -27,6 +28,11 @@ type MessageContextUserSettings struct {
 	ASREnabled            bool   `json:"asr_enabled"`
 	ASREnableComponentID  string `json:"asr_enable_component_id"`
 	ASRDisableComponentID string `json:"asr_disable_component_id"`
+
+	HistoryAvailable          bool   `json:"history_available"`
+	HistoryEnabled            bool   `json:"history_enabled"`
+	HistoryEnableComponentID  string `json:"history_enable_component_id"`
+	HistoryDisableComponentID string `json:"history_disable_component_id"`
 }
 type MessageContextUserSettingsToggleResponse struct {
 	Enabled bool   `json:"enabled"`
@@ -51,6 +57,17 @@ type MessageContextAsrResult struct {
 	Text          string             `json:"text"`
 	CallerMessage *discordgo.Message `json:"caller_message"`
 	Duration      float64            `json:"duration"`
+	// LowConfidenceReason is set if the transcript looks hallucinated
+	LowConfidenceReason string `json:"low_confidence_reason"`
+}
+type MessageContextAsrNoSpeech struct {
+	CallerMessage *discordgo.Message `json:"caller_message"`
+	Duration      float64            `json:"duration"`
+}
+type MessageContextAsrRateLimited struct {
+	Reason string `json:"reason"`
+	// unix timestamp, 0 if unknown
+	RetryAt int64 `json:"retry_at"`
 }
 type MessageContextAsrNudge struct {
 	ASREnableComponentID string `json:"asr_enable_component_id"`
@@ -59,6 +76,78 @@ type MessageContextAsrNudge struct {
 	ChannelID string           `json:"channel_id"`
 }
 
+type MessageContextHistoryEntry struct {
+	Text       string `json:"text"`
+	CreatedAt  int64  `json:"created_at"`
+	MessageURL string `json:"message_url"`
+}
+type MessageContextHistoryPage struct {
+	Entries        []MessageContextHistoryEntry `json:"entries"`
+	Query          string                       `json:"query"`
+	Page           int                          `json:"page"`
+	HistoryEnabled bool                         `json:"history_enabled"`
+
+	HasPrevious         bool   `json:"has_previous"`
+	HasNext             bool   `json:"has_next"`
+	PreviousComponentID string `json:"previous_component_id"`
+	NextComponentID     string `json:"next_component_id"`
+}
+
+type MessageContextPrivacyMenu struct {
+	ExportComponentID string `json:"export_component_id"`
+	DeleteComponentID string `json:"delete_component_id"`
+}
+type MessageContextPrivacyDeleteConfirm struct {
+	ConfirmComponentID    string `json:"confirm_component_id"`
+	ConfirmAllComponentID string `json:"confirm_all_component_id"`
+	CancelComponentID     string `json:"cancel_component_id"`
+}
+type MessageContextPrivacyExport struct {
+	Transcriptions int `json:"transcriptions"`
+	Transcripts    int `json:"transcripts"`
+}
+type MessageContextPrivacyDeleteResult struct {
+	Transcriptions int `json:"transcriptions"`
+	Transcripts    int `json:"transcripts"`
+	RepliesDeleted int `json:"replies_deleted"`
+	RepliesFailed  int `json:"replies_failed"`
+
+	// ErrorMessage is set if deletion failed or didn't finish
+	ErrorMessage string `json:"error_message"`
+}
+
+type MessageContextGuildSettings struct {
+	// effective quotas in seconds, 0 is unlimited
+	UserDailyAudioSeconds         float64 `json:"user_daily_audio_seconds"`
+	GuildDailyAudioSeconds        float64 `json:"guild_daily_audio_seconds"`
+	DefaultUserDailyAudioSeconds  float64 `json:"default_user_daily_audio_seconds"`
+	DefaultGuildDailyAudioSeconds float64 `json:"default_guild_daily_audio_seconds"`
+	UserDailyAudioOverridden      bool    `json:"user_daily_audio_overridden"`
+	GuildDailyAudioOverridden     bool    `json:"guild_daily_audio_overridden"`
+	// "flag" or "suppress"
+	LowConfidenceAction string `json:"low_confidence_action"`
+}
+
+type MessageContextStatsRow struct {
+	Name              string  `json:"name"`
+	Transcriptions    int64   `json:"transcriptions"`
+	Failed            int64   `json:"failed"`
+	AudioMinutes      float64 `json:"audio_minutes"`
+	ProcessingTimeP50 float64 `json:"processing_time_p50"`
+	ProcessingTimeP95 float64 `json:"processing_time_p95"`
+	EstimatedCost     float64 `json:"estimated_cost"`
+}
+type MessageContextStats struct {
+	Global    bool   `json:"global"`
+	GuildName string `json:"guild_name"`
+	Days      int    `json:"days"`
+
+	Total   MessageContextStatsRow   `json:"total"`
+	ByModel []MessageContextStatsRow `json:"by_model"`
+	ByDay   []MessageContextStatsRow `json:"by_day"`
+	ByGuild []MessageContextStatsRow `json:"by_guild"`
+}
+
 type MessageContext struct {
 	UserSettings               *MessageContextUserSettings               `json:"user_settings"`
 	UserSettingsToggleResponse *MessageContextUserSettingsToggleResponse `json:"user_settings_toggle_response"`
@@ -70,7 +159,23 @@ type MessageContext struct {
 	AsrProgress *MessageContextAsrProgress `json:"asr_progress,omitempty"`
 	AsrResult   *MessageContextAsrResult   `json:"asr_result,omitempty"`
 	AsrNudge    *MessageContextAsrNudge    `json:"asr_nudge,omitempty"`
+	AsrNoSpeech *MessageContextAsrNoSpeech `json:"asr_no_speech,omitempty"`
+
+	AsrRateLimited *MessageContextAsrRateLimited `json:"asr_rate_limited,omitempty"`
+
+	HistoryPage *MessageContextHistoryPage `json:"history_page,omitempty"`
+
+	GuildSettings *MessageContextGuildSettings `json:"guild_settings,omitempty"`
+	Stats         *MessageContextStats         `json:"stats,omitempty"`
+
+	PrivacyMenu          *MessageContextPrivacyMenu          `json:"privacy_menu,omitempty"`
+	PrivacyDeleteConfirm *MessageContextPrivacyDeleteConfirm `json:"privacy_delete_confirm,omitempty"`
+	PrivacyExport        *MessageContextPrivacyExport        `json:"privacy_export,omitempty"`
+	PrivacyDeleteResult  *MessageContextPrivacyDeleteResult  `json:"privacy_delete_result,omitempty"`
 
+	// Locale is the Discord locale to render in, such as "pt-BR", falling
+	// back to en-US
+	Locale             string                                   `json:"locale"`
 	Timestamp          string                                   `json:"timestamp"`
 	RegisteredCommands map[string]*discordgo.ApplicationCommand `json:"registered_commands"`
 }
@@ -79,17 +184,35 @@ func (b *DiscordBot) executeMessageTemplate(ctx context.Context, messageName str
 	log := utils.GetLogFromContext(ctx, b.log)
 
 	data.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	if data.Locale == "" {
+		data.Locale = localeFromContext(ctx)
+	}
 	b.commandsMu.RLock()
 	data.RegisteredCommands = b.commands
 	defer b.commandsMu.RUnlock()
 
 	jsonOut, err := b.messages.ExecuteMessage(messageName, data)
 	if err != nil {
+		metrics.TemplateRenderFailures.WithLabelValues(messageName).Inc()
 		return nil, err
 	}
 
+	output, err := DecodeMessageOutput(jsonOut)
+	if err != nil {
+		metrics.TemplateRenderFailures.WithLabelValues(messageName).Inc()
+		return nil, err
+	}
+
+	log.With(zap.Any("output", output)).Debug("got message template output")
+
+	return output, nil
+}
+
+// DecodeMessageOutput decodes the JSON a message template renders to,
+// turning its components into discordgo's types.
+func DecodeMessageOutput(jsonOut string) (*MessageOutput, error) {
 	var outputRaw messageOutputRaw
-	err = json.Unmarshal([]byte(jsonOut), &outputRaw)
+	err := json.Unmarshal([]byte(jsonOut), &outputRaw)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshaling output: %w", err)
 	}
@@ -99,21 +222,13 @@ func (b *DiscordBot) executeMessageTemplate(ctx context.Context, messageName str
 		Embeds:  outputRaw.Embeds,
 	}
 
-	if outputRaw.Components != nil {
-		for _, c := range outputRaw.Components {
-			bytes, err := c.MarshalJSON()
-			if err != nil {
-				return nil, fmt.Errorf("marshaling component: %w", err)
-			}
-			messageComponent, err := discordgo.MessageComponentFromJSON(bytes)
-			if err != nil {
-				return nil, fmt.Errorf("unmarshaling component: %w", err)
-			}
-			output.Components = append(output.Components, messageComponent)
+	for _, c := range outputRaw.Components {
+		messageComponent, err := discordgo.MessageComponentFromJSON(c)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling component: %w", err)
 		}
+		output.Components = append(output.Components, messageComponent)
 	}
 
-	log.With(zap.Any("output", output)).Debug("got message template output")
-
 	return output, nil
 }
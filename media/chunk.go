@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/K3das/orange/tracing"
+	"github.com/K3das/orange/utils"
+)
+
+type ChunkOptions struct {
+	// MaxDuration is the longest a chunk can be, in seconds
+	MaxDuration float64
+	// MinDuration is the shortest a chunk split at silence can be, so short
+	// pauses early in a chunk don't make tiny chunks
+	MinDuration float64
+	// Overlap is how much chunks overlap when there's no silence to split at
+	Overlap float64
+
+	// SilenceNoise is the level in dB below which audio counts as silence
+	SilenceNoise float64
+	// SilenceMinDuration is the shortest silence to split at, in seconds
+	SilenceMinDuration float64
+}
+
+var DefaultChunkOptions = ChunkOptions{
+	MaxDuration:        120,
+	MinDuration:        30,
+	Overlap:            2,
+	SilenceNoise:       -35,
+	SilenceMinDuration: 0.4,
+}
+
+type SilenceInterval struct {
+	Start float64
+	End   float64
+}
+
+type AudioChunk struct {
+	// Start and End of the chunk in the original audio, in seconds
+	Start float64
+	End   float64
+
+	Data []byte
+}
+
+// FFmpegDetectSilence finds silences in the input with ffmpeg's silencedetect
+// filter. A silence running until the end of the input ends at duration.
+func (f *FFmpeg) FFmpegDetectSilence(ctx context.Context, input []byte, duration, noise, minDuration float64) (silences []SilenceInterval, err error) {
+	ctx, span := tracing.Start(ctx, "ffmpeg.detect_silence")
+	defer func() { tracing.End(span, err) }()
+
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, f.commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx,
+		f.ffmpegBinary,
+		"-hide_banner",
+		"-nostats",
+		"-i", "pipe:0",
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", noise, minDuration),
+		"-f", "null",
+		"-",
+	)
+	cmd.Stdin = bytes.NewReader(input)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stderr pipe: %w", err)
+	}
+
+	err = f.start(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("starting ffmpeg: %w", err)
+	}
+
+	// silencedetect logs to stderr, which is also kept for errors
+	stderrTail := &stderrTail{}
+	silences = parseSilenceDetect(io.TeeReader(stderr, stderrTail), duration)
+
+	err = cmd.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("running ffmpeg: %w", commandError(err, stderrTail))
+	}
+
+	return silences, nil
+}
+
+// parseSilenceDetect parses silencedetect's log lines like
+// `[silencedetect @ 0x0] silence_start: 1.5` and
+// `[silencedetect @ 0x0] silence_end: 2.5 | silence_duration: 1`.
+func parseSilenceDetect(r io.Reader, duration float64) []SilenceInterval {
+	var silences []SilenceInterval
+	start := -1.0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if _, value, ok := strings.Cut(line, "silence_start: "); ok {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				start = max(parsed, 0)
+			}
+		} else if _, value, ok := strings.Cut(line, "silence_end: "); ok && start >= 0 {
+			value, _, _ = strings.Cut(value, " ")
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				silences = append(silences, SilenceInterval{Start: start, End: parsed})
+			}
+			start = -1
+		}
+	}
+	io.Copy(io.Discard, r)
+
+	if start >= 0 {
+		silences = append(silences, SilenceInterval{Start: start, End: duration})
+	}
+
+	return silences
+}
+
+// chunkBoundaries splits [0, duration] into chunks without data no longer than
+// options.MaxDuration, cutting at the middle of the latest silence in each
+// chunk, or overlapping the next chunk if there's none.
+func chunkBoundaries(duration float64, silences []SilenceInterval, options ChunkOptions) []AudioChunk {
+	var chunks []AudioChunk
+
+	start := 0.0
+	for duration-start > options.MaxDuration {
+		limit := start + options.MaxDuration
+
+		end := -1.0
+		for _, silence := range silences {
+			middle := (silence.Start + silence.End) / 2
+			if middle > start+options.MinDuration && middle <= limit {
+				end = middle
+			}
+		}
+
+		next := end
+		if end < 0 {
+			end = limit
+			next = limit - options.Overlap
+		}
+
+		chunks = append(chunks, AudioChunk{Start: start, End: end})
+		start = next
+	}
+
+	return append(chunks, AudioChunk{Start: start, End: duration})
+}
+
+// FFmpegSplitAudio splits the input into chunks of at most
+// options.MaxDuration, preferring to split at silences, each encoded with the
+// profile. The input must be in a container ffmpeg can read from a pipe.
+func (f *FFmpeg) FFmpegSplitAudio(ctx context.Context, input []byte, duration float64, profile EncodingProfile, options ChunkOptions, maxChunkSize int) (chunks []AudioChunk, err error) {
+	ctx, span := tracing.Start(ctx, "ffmpeg.split_audio")
+	defer func() { tracing.End(span, err) }()
+
+	silences, err := f.FFmpegDetectSilence(ctx, input, duration, options.SilenceNoise, options.SilenceMinDuration)
+	if err != nil {
+		return nil, fmt.Errorf("detecting silence: %w", err)
+	}
+
+	chunks = chunkBoundaries(duration, silences, options)
+	for i := range chunks {
+		chunk := &chunks[i]
+		chunk.Data, err = f.ffmpegExtractAudio(ctx, input, chunk.Start, chunk.End, profile, maxChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("extracting chunk at %fs: %w", chunk.Start, err)
+		}
+	}
+
+	return chunks, nil
+}
+
+func (f *FFmpeg) ffmpegExtractAudio(ctx context.Context, input []byte, start, end float64, profile EncodingProfile, maxSize int) ([]byte, error) {
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, f.commandTimeout)
+	defer cancel()
+
+	args := []string{
+		"-v", "error",
+		"-i", "pipe:0",
+		"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+		"-t", strconv.FormatFloat(end-start, 'f', 3, 64),
+	}
+	args = append(args, profile.outputArgs()...)
+	args = append(args, "pipe:1")
+
+	cmd := exec.CommandContext(ctx, f.ffmpegBinary, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	stderr := captureStderr(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+
+	err = f.start(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("starting ffmpeg: %w", err)
+	}
+
+	output, err := utils.ReadAllLimit(stdout, maxSize)
+	if err != nil {
+		cancel()
+		cmd.Wait()
+		return nil, fmt.Errorf("reading output: %w", err)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("running ffmpeg: %w", commandError(err, stderr))
+	}
+
+	return output, nil
+}
@@ -5,42 +5,52 @@ import (
 	"fmt"
 	"os/exec"
 
+	"github.com/K3das/orange/tracing"
 	"github.com/K3das/orange/utils"
 )
 
-// FFmpegResampleAudioFromFile resamples the input to 48kHz and encodes as aac, outputting the data as bytes
-func (f *FFmpeg) FFmpegResampleAudioFromFile(ctx context.Context, filePath string, maxSize int) ([]byte, error) {
+// FFmpegResampleAudioFromFile resamples and encodes the input with the profile, outputting the data as bytes
+func (f *FFmpeg) FFmpegResampleAudioFromFile(ctx context.Context, filePath string, profile EncodingProfile, maxSize int) (output []byte, err error) {
+	ctx, span := tracing.Start(ctx, "ffmpeg.resample")
+	defer func() { tracing.End(span, err) }()
+
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	ctx, cancel := context.WithTimeout(ctx, f.commandTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx,
-		f.ffmpegBinary,
-		"-i", filePath,
-		"-c:a", "aac",
-		"-ar:a", "48000",
-		"-ac:a", "1",
-		"-f", "adts",
-		"-",
-	)
+	args := []string{"-i", filePath}
+	args = append(args, f.filterArgs(false)...)
+	args = append(args, profile.outputArgs()...)
+	args = append(args, "-")
+
+	cmd := exec.CommandContext(ctx, f.ffmpegBinary, args...)
+	stderr := captureStderr(cmd)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("creating stdout pipe: %w", err)
 	}
 
-	err = cmd.Start()
+	err = f.start(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("starting ffmpeg: %w", err)
 	}
 
-	output, err := utils.ReadAllLimit(stdout, maxSize)
+	output, err = utils.ReadAllLimit(stdout, maxSize)
 	if err != nil {
+		cancel()
+		cmd.Wait()
 		return nil, fmt.Errorf("reading output: %w", err)
 	}
 
 	err = cmd.Wait()
 	if err != nil {
-		return nil, fmt.Errorf("running ffmpeg: %w", err)
+		return nil, fmt.Errorf("running ffmpeg: %w", commandError(err, stderr))
 	}
 
 	return output, nil
@@ -1,11 +1,14 @@
 package media
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strconv"
+
+	"github.com/K3das/orange/tracing"
 )
 
 var ErrFFprobeDurationInvalid = fmt.Errorf("got no packets from ffprobe, likely a bad file")
@@ -39,14 +42,23 @@ func (f *FFmpeg) ffprobeGetPacketsFromFile(ctx context.Context, filePath string)
 		"-print_format", "json",
 		"-show_packets",
 	)
+	stderr := captureStderr(cmd)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+
+	err := f.start(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("starting ffprobe: %w", err)
+	}
 
-	output, err := cmd.Output()
+	err = cmd.Wait()
 	if err != nil {
-		return nil, fmt.Errorf("running ffprobe: %w", err)
+		return nil, fmt.Errorf("running ffprobe: %w", commandError(err, stderr))
 	}
 
 	var response FFprobePacketsOutput
-	err = json.Unmarshal(output, &response)
+	err = json.Unmarshal(output.Bytes(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("parsing ffprobe json response: %w", err)
 	}
@@ -79,7 +91,16 @@ func (f *FFmpeg) ffprobeGetPacketsFromFile(ctx context.Context, filePath string)
 // This uses packet metadata because some containers don't really include duration
 // metadata (like the MediaRecorder API's output), and it's more accurate to
 // what is processed by the model.
-func (f *FFmpeg) FFprobeDurationFromFile(ctx context.Context, filePath string) (float64, error) {
+func (f *FFmpeg) FFprobeDurationFromFile(ctx context.Context, filePath string) (duration float64, err error) {
+	ctx, span := tracing.Start(ctx, "ffprobe.duration")
+	defer func() { tracing.End(span, err) }()
+
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
 	ctx, cancel := context.WithTimeout(ctx, f.commandTimeout)
 	defer cancel()
 
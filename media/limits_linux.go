@@ -0,0 +1,30 @@
+//go:build linux
+
+package media
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+func applyProcessLimits(pid int, niceness int, memoryLimit uint64) error {
+	if niceness != 0 {
+		err := unix.Setpriority(unix.PRIO_PROCESS, pid, niceness)
+		if err != nil {
+			return fmt.Errorf("setting niceness: %w", err)
+		}
+	}
+
+	if memoryLimit > 0 {
+		err := unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{
+			Cur: memoryLimit,
+			Max: memoryLimit,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("setting memory limit: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package media
+
+// process limits are only supported on Linux
+func applyProcessLimits(pid int, niceness int, memoryLimit uint64) error {
+	return nil
+}
@@ -1,7 +1,11 @@
 package media
 
 import (
+	"fmt"
+	"os/exec"
 	"time"
+
+	"golang.org/x/sync/semaphore"
 )
 
 const DefaultFFmpegBinary = "ffmpeg"
@@ -15,6 +19,13 @@ type FFmpeg struct {
 	ffmpegBinary   string
 	ffprobeBinary  string
 	commandTimeout time.Duration
+
+	preprocess PreprocessOptions
+
+	// nil if unlimited
+	sem         *semaphore.Weighted
+	niceness    int
+	memoryLimit uint64
 }
 
 func WithFFmpegBinary(ffmpegBinary string) FFmpegOptions {
@@ -40,6 +51,7 @@ func NewFFmpeg(options ...FFmpegOptions) *FFmpeg {
 		ffmpegBinary:   DefaultFFmpegBinary,
 		ffprobeBinary:  DefaultFFprobeBinary,
 		commandTimeout: DefaultCommandTimeout,
+		preprocess:     DefaultPreprocessOptions,
 	}
 
 	for _, option := range options {
@@ -48,3 +60,14 @@ func NewFFmpeg(options ...FFmpegOptions) *FFmpeg {
 
 	return ffmpeg
 }
+
+// CheckBinaries checks that the ffmpeg and ffprobe binaries can be found.
+func (f *FFmpeg) CheckBinaries() error {
+	for _, binary := range []string{f.ffmpegBinary, f.ffprobeBinary} {
+		_, err := exec.LookPath(binary)
+		if err != nil {
+			return fmt.Errorf("finding %s: %w", binary, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotOggOpus = errors.New("not an ogg opus stream")
+
+// Opus granule positions are always in 48kHz samples
+const opusGranuleRate = 48000
+
+const (
+	oggPageHeaderSize = 27
+	oggFlagBOS        = 0x02
+	opusHeadSize      = 19
+)
+
+var (
+	oggCapturePattern = []byte("OggS")
+	opusHeadMagic     = []byte("OpusHead")
+)
+
+type OggOpusInfo struct {
+	Channels        int
+	PreSkip         int
+	InputSampleRate uint32
+	// OutputGain in Q7.8 dB
+	OutputGain int16
+
+	// GranulePosition is the last granule position of the stream
+	GranulePosition int64
+	// Duration in seconds, from the last granule position minus pre-skip
+	Duration float64
+}
+
+// OggOpusParser reads the Opus header and the last granule position of the
+// first logical stream written to it. It never returns write errors so it can
+// be used with io.TeeReader; parsing errors are returned from Info.
+type OggOpusParser struct {
+	buf []byte
+	err error
+
+	serial  uint32
+	info    *OggOpusInfo
+	granule int64
+}
+
+func (p *OggOpusParser) Write(b []byte) (int, error) {
+	if p.err != nil {
+		return len(b), nil
+	}
+
+	p.buf = append(p.buf, b...)
+	p.parsePages()
+
+	return len(b), nil
+}
+
+func (p *OggOpusParser) parsePages() {
+	for p.err == nil && len(p.buf) >= oggPageHeaderSize {
+		if !bytes.Equal(p.buf[:4], oggCapturePattern) {
+			p.err = fmt.Errorf("%w: missing page capture pattern", ErrNotOggOpus)
+			return
+		}
+
+		segments := int(p.buf[26])
+		if len(p.buf) < oggPageHeaderSize+segments {
+			return
+		}
+		dataSize := 0
+		for _, lacing := range p.buf[oggPageHeaderSize : oggPageHeaderSize+segments] {
+			dataSize += int(lacing)
+		}
+		pageSize := oggPageHeaderSize + segments + dataSize
+		if len(p.buf) < pageSize {
+			return
+		}
+
+		flags := p.buf[5]
+		granule := int64(binary.LittleEndian.Uint64(p.buf[6:14]))
+		serial := binary.LittleEndian.Uint32(p.buf[14:18])
+		data := p.buf[oggPageHeaderSize+segments : pageSize]
+
+		if p.info == nil {
+			p.parseHead(flags, serial, data)
+		} else if serial == p.serial && granule != -1 {
+			// -1 means no packet finishes on this page
+			p.granule = granule
+		}
+
+		p.buf = p.buf[pageSize:]
+	}
+}
+
+func (p *OggOpusParser) parseHead(flags byte, serial uint32, data []byte) {
+	if flags&oggFlagBOS == 0 {
+		p.err = fmt.Errorf("%w: first page isn't the start of a stream", ErrNotOggOpus)
+		return
+	}
+	if len(data) < opusHeadSize || !bytes.Equal(data[:8], opusHeadMagic) {
+		p.err = fmt.Errorf("%w: missing OpusHead", ErrNotOggOpus)
+		return
+	}
+
+	p.serial = serial
+	p.info = &OggOpusInfo{
+		Channels:        int(data[9]),
+		PreSkip:         int(binary.LittleEndian.Uint16(data[10:12])),
+		InputSampleRate: binary.LittleEndian.Uint32(data[12:16]),
+		OutputGain:      int16(binary.LittleEndian.Uint16(data[16:18])),
+	}
+}
+
+// Info returns the stream info from everything written so far, so it should
+// be called after the whole stream was written.
+func (p *OggOpusParser) Info() (*OggOpusInfo, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.info == nil {
+		return nil, fmt.Errorf("%w: no header", ErrNotOggOpus)
+	}
+	if len(p.buf) > 0 {
+		return nil, fmt.Errorf("truncated page")
+	}
+
+	info := *p.info
+	info.GranulePosition = p.granule
+	info.Duration = float64(max(p.granule-int64(info.PreSkip), 0)) / opusGranuleRate
+
+	return &info, nil
+}
+
+// ParseOggOpus reads all of r, returning the Opus stream info.
+func ParseOggOpus(r io.Reader) (*OggOpusInfo, error) {
+	p := &OggOpusParser{}
+	_, err := io.Copy(p, r)
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	return p.Info()
+}
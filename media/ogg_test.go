@@ -0,0 +1,102 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	return data
+}
+
+func TestParseOggOpus(t *testing.T) {
+	info, err := ParseOggOpus(bytes.NewReader(readFixture(t, "opus.ogg")))
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+
+	want := OggOpusInfo{
+		Channels:        1,
+		PreSkip:         312,
+		InputSampleRate: 48000,
+		GranulePosition: 96312,
+		Duration:        2,
+	}
+	if *info != want {
+		t.Errorf("got %+v, want %+v", *info, want)
+	}
+}
+
+func TestOggOpusParserStreaming(t *testing.T) {
+	// written a byte at a time, like small reads through a TeeReader
+	p := &OggOpusParser{}
+	r := io.TeeReader(iotest.OneByteReader(bytes.NewReader(readFixture(t, "opus.ogg"))), p)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+
+	info, err := p.Info()
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if info.Duration != 2 {
+		t.Errorf("duration is %f, want 2", info.Duration)
+	}
+}
+
+func TestOggOpusParserContinuedPacket(t *testing.T) {
+	// up to the end of the page with only the start of a packet, which has
+	// no granule position
+	const pagesSize = 47 + 50 + 330 + 539
+	data := readFixture(t, "opus.ogg")[:pagesSize]
+
+	info, err := ParseOggOpus(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if info.GranulePosition != 24312 || info.Duration != 0.5 {
+		t.Errorf("got granule %d and duration %f, want 24312 and 0.5", info.GranulePosition, info.Duration)
+	}
+}
+
+func TestParseOggOpusErrors(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		notOpus  bool
+		contains string
+	}{
+		{fixture: "opus_truncated.ogg", contains: "truncated"},
+		{fixture: "vorbis.ogg", notOpus: true},
+		{fixture: "not_ogg.wav", notOpus: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			_, err := ParseOggOpus(bytes.NewReader(readFixture(t, tt.fixture)))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if errors.Is(err, ErrNotOggOpus) != tt.notOpus {
+				t.Errorf("error %q, want ErrNotOggOpus: %t", err, tt.notOpus)
+			}
+			if tt.contains != "" && !bytes.Contains([]byte(err.Error()), []byte(tt.contains)) {
+				t.Errorf("error %q doesn't mention %q", err, tt.contains)
+			}
+		})
+	}
+}
+
+func TestOggOpusParserEmpty(t *testing.T) {
+	if _, err := ParseOggOpus(bytes.NewReader(nil)); !errors.Is(err, ErrNotOggOpus) {
+		t.Errorf("got %v, want ErrNotOggOpus", err)
+	}
+}
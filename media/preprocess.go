@@ -0,0 +1,89 @@
+package media
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreprocessOptions are filters applied to audio before it's encoded for the
+// ASR API, to reduce hallucinations on quiet or noisy audio.
+type PreprocessOptions struct {
+	// TrimSilence removes leading silence and shortens pauses longer than 2
+	// seconds, which shifts segment timestamps
+	TrimSilence bool `env:"TRIM_SILENCE"`
+	// TrimSilenceThreshold is the level in dB below which audio is trimmed
+	TrimSilenceThreshold float64 `env:"TRIM_SILENCE_THRESHOLD" envDefault:"-50"`
+
+	// Loudnorm normalizes loudness with EBU R128
+	Loudnorm bool `env:"LOUDNORM"`
+
+	// HighpassFrequency in Hz cuts rumble below it, 0 disables it
+	HighpassFrequency int `env:"HIGHPASS_FREQUENCY"`
+
+	// NoSpeechThreshold is the level in dB below which audio counts as
+	// silent, measured before Loudnorm
+	NoSpeechThreshold float64 `env:"NO_SPEECH_THRESHOLD" envDefault:"-50"`
+}
+
+// DefaultPreprocessOptions has no filters enabled
+var DefaultPreprocessOptions = PreprocessOptions{
+	TrimSilenceThreshold: -50,
+	NoSpeechThreshold:    -50,
+}
+
+// the shortest audio that can have speech in it, in seconds
+const minSpeechDuration = 0.1
+
+// the fraction of audio that has to be silent for it to have no speech
+const silentFraction = 0.98
+
+func WithPreprocessing(options PreprocessOptions) FFmpegOptions {
+	return func(f *FFmpeg) {
+		f.preprocess = options
+	}
+}
+
+// filterArgs are the ffmpeg options for the preprocessing filters, if any are
+// enabled. With detectSilence, silencedetect logs the silences below
+// NoSpeechThreshold before loudness normalization, which would lift a quiet
+// recording's noise floor above it.
+func (f *FFmpeg) filterArgs(detectSilence bool) []string {
+	var filters []string
+
+	if f.preprocess.HighpassFrequency > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", f.preprocess.HighpassFrequency))
+	}
+	if f.preprocess.TrimSilence {
+		threshold := f.preprocess.TrimSilenceThreshold
+		filters = append(filters, fmt.Sprintf(
+			"silenceremove=start_periods=1:start_threshold=%gdB:stop_periods=-1:stop_duration=2:stop_threshold=%gdB",
+			threshold, threshold,
+		))
+	}
+	if detectSilence {
+		filters = append(filters, fmt.Sprintf("silencedetect=noise=%gdB:d=%g", f.preprocess.NoSpeechThreshold, minSpeechDuration))
+	}
+	if f.preprocess.Loudnorm {
+		filters = append(filters, "loudnorm=I=-16:TP=-1.5:LRA=11")
+	}
+
+	if len(filters) == 0 {
+		return nil
+	}
+	return []string{"-af", strings.Join(filters, ",")}
+}
+
+// isSilent reports whether audio has no speech: it's too short after
+// preprocessing, or almost all of it is in silences.
+func isSilent(silences []SilenceInterval, duration float64) bool {
+	if duration < minSpeechDuration {
+		return true
+	}
+
+	var silent float64
+	for _, silence := range silences {
+		silent += silence.End - silence.Start
+	}
+
+	return silent >= duration*silentFraction
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/K3das/orange/metrics"
+	"golang.org/x/sync/semaphore"
+)
+
+// how much of a process's stderr is kept for errors
+const stderrTailSize = 2048
+
+func WithMaxConcurrency(n int) FFmpegOptions {
+	return func(f *FFmpeg) {
+		if n > 0 {
+			f.sem = semaphore.NewWeighted(int64(n))
+		}
+	}
+}
+
+// WithNiceness sets the niceness of started processes, so they yield to the
+// bot itself. Only supported on Linux.
+func WithNiceness(niceness int) FFmpegOptions {
+	return func(f *FFmpeg) {
+		f.niceness = niceness
+	}
+}
+
+// WithMemoryLimit limits the address space of started processes in bytes, 0
+// is unlimited. Only supported on Linux.
+func WithMemoryLimit(bytes uint64) FFmpegOptions {
+	return func(f *FFmpeg) {
+		f.memoryLimit = bytes
+	}
+}
+
+// acquire waits until a process can be started, returning a function that
+// must be called once it exited.
+func (f *FFmpeg) acquire(ctx context.Context) (func(), error) {
+	if f.sem == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+	metrics.MediaProcessesWaiting.Inc()
+	err := f.sem.Acquire(ctx, 1)
+	metrics.MediaProcessesWaiting.Dec()
+	metrics.MediaProcessWaitDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("waiting to start process: %w", err)
+	}
+
+	metrics.MediaProcessesRunning.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			metrics.MediaProcessesRunning.Dec()
+			f.sem.Release(1)
+		})
+	}, nil
+}
+
+// start starts the command and applies resource limits to it, killing it if
+// they can't be applied.
+func (f *FFmpeg) start(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	err = applyProcessLimits(cmd.Process.Pid, f.niceness, f.memoryLimit)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("applying limits: %w", err)
+	}
+
+	return nil
+}
+
+// stderrTail keeps the end of what's written to it.
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > stderrTailSize {
+		t.buf = t.buf[len(t.buf)-stderrTailSize:]
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return strings.TrimSpace(string(t.buf))
+}
+
+// captureStderr keeps the end of the command's stderr for CommandError.
+func captureStderr(cmd *exec.Cmd) *stderrTail {
+	tail := &stderrTail{}
+	cmd.Stderr = tail
+	return tail
+}
+
+// CommandError is a failed process with the end of its stderr.
+type CommandError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *CommandError) Error() string {
+	if e.Stderr == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Stderr)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func commandError(err error, stderr *stderrTail) error {
+	return &CommandError{
+		Err:    err,
+		Stderr: stderr.String(),
+	}
+}
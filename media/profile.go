@@ -0,0 +1,69 @@
+package media
+
+import "strconv"
+
+// EncodingProfile is the format audio is transcoded to.
+type EncodingProfile struct {
+	// Codec is the ffmpeg encoder, like "aac" or "flac"
+	Codec      string
+	SampleRate int
+	Channels   int
+	// Container is the ffmpeg muxer, like "adts" or "wav"
+	Container string
+	// Bitrate like "32k", empty uses the encoder's default
+	Bitrate string
+}
+
+var (
+	// ProfileAAC48kMono is what Orange always used before profiles, and the
+	// default for providers without a preference
+	ProfileAAC48kMono = EncodingProfile{
+		Codec:      "aac",
+		SampleRate: 48000,
+		Channels:   1,
+		Container:  "adts",
+	}
+	// ProfileAAC16kMono is for Whisper models, which resample to 16kHz anyway
+	ProfileAAC16kMono = EncodingProfile{
+		Codec:      "aac",
+		SampleRate: 16000,
+		Channels:   1,
+		Container:  "adts",
+		Bitrate:    "32k",
+	}
+	ProfileWAV16kMono = EncodingProfile{
+		Codec:      "pcm_s16le",
+		SampleRate: 16000,
+		Channels:   1,
+		Container:  "wav",
+	}
+	ProfileFLAC16kMono = EncodingProfile{
+		Codec:      "flac",
+		SampleRate: 16000,
+		Channels:   1,
+		Container:  "flac",
+	}
+	ProfileOpus16kMono = EncodingProfile{
+		Codec:      "libopus",
+		SampleRate: 16000,
+		Channels:   1,
+		Container:  "ogg",
+		Bitrate:    "24k",
+	}
+)
+
+var DefaultEncodingProfile = ProfileAAC48kMono
+
+// outputArgs are the ffmpeg output options for the profile, without the
+// output itself.
+func (p EncodingProfile) outputArgs() []string {
+	args := []string{
+		"-c:a", p.Codec,
+		"-ar:a", strconv.Itoa(p.SampleRate),
+		"-ac:a", strconv.Itoa(p.Channels),
+	}
+	if p.Bitrate != "" {
+		args = append(args, "-b:a", p.Bitrate)
+	}
+	return append(args, "-f", p.Container)
+}
@@ -0,0 +1,196 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/K3das/orange/tracing"
+	"github.com/K3das/orange/utils"
+)
+
+var ErrFFmpegDurationInvalid = fmt.Errorf("got no progress from ffmpeg, likely a bad file")
+
+type ResampleOutput struct {
+	Data []byte
+	// Duration of the output audio in seconds
+	Duration float64
+	// Silent is set if the audio has no speech, it's only detected by
+	// FFmpegProbeAndResampleAudio
+	Silent bool
+}
+
+// FFmpegResampleAudio is like FFmpegResampleAudioFromFile, but reads up to
+// maxInputSize bytes of input from r instead of a file.
+func (f *FFmpeg) FFmpegResampleAudio(ctx context.Context, r io.Reader, profile EncodingProfile, maxInputSize, maxOutputSize int) (output []byte, err error) {
+	ctx, span := tracing.Start(ctx, "ffmpeg.resample_stream")
+	defer func() { tracing.End(span, err) }()
+
+	resampled, err := f.resampleAudioStream(ctx, r, profile, maxInputSize, maxOutputSize, false)
+	if err != nil {
+		return nil, err
+	}
+	return resampled.Data, nil
+}
+
+// FFmpegProbeAndResampleAudio resamples audio read from r like
+// FFmpegResampleAudio, also getting the duration of the output from ffmpeg's
+// progress reports, so the input doesn't need to be probed separately, and
+// whether it has no speech. Silent audio makes Whisper hallucinate.
+//
+// Returns ErrFFmpegDurationInvalid if ffmpeg reported no duration.
+func (f *FFmpeg) FFmpegProbeAndResampleAudio(ctx context.Context, r io.Reader, profile EncodingProfile, maxInputSize, maxOutputSize int) (resampled *ResampleOutput, err error) {
+	ctx, span := tracing.Start(ctx, "ffmpeg.probe_resample_stream")
+	defer func() { tracing.End(span, err) }()
+
+	return f.resampleAudioStream(ctx, r, profile, maxInputSize, maxOutputSize, true)
+}
+
+func (f *FFmpeg) resampleAudioStream(ctx context.Context, r io.Reader, profile EncodingProfile, maxInputSize, maxOutputSize int, withProgress bool) (*ResampleOutput, error) {
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, f.commandTimeout)
+	defer cancel()
+
+	// silencedetect logs at the info level
+	logLevel := "error"
+	if withProgress {
+		logLevel = "info"
+	}
+	args := []string{
+		"-hide_banner",
+		"-v", logLevel,
+		"-i", "pipe:0",
+	}
+	args = append(args, f.filterArgs(withProgress)...)
+	args = append(args, profile.outputArgs()...)
+	if withProgress {
+		// ExtraFiles[0] is fd 3 in the child
+		args = append(args, "-progress", "pipe:3", "-nostats")
+	}
+	args = append(args, "pipe:1")
+
+	cmd := exec.CommandContext(ctx, f.ffmpegBinary, args...)
+	stderr := captureStderr(cmd)
+	var silenceLog bytes.Buffer
+	if withProgress {
+		cmd.Stderr = io.MultiWriter(stderr, &silenceLog)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+
+	var progressReader *os.File
+	if withProgress {
+		var progressWriter *os.File
+		progressReader, progressWriter, err = os.Pipe()
+		if err != nil {
+			return nil, fmt.Errorf("creating progress pipe: %w", err)
+		}
+		defer progressReader.Close()
+		cmd.ExtraFiles = []*os.File{progressWriter}
+	}
+
+	err = f.start(cmd)
+	if withProgress {
+		// the child has its own copy of the write end
+		cmd.ExtraFiles[0].Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("starting ffmpeg: %w", err)
+	}
+
+	var wg sync.WaitGroup
+
+	var inputErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer stdin.Close()
+		_, inputErr = utils.CopyLimit(stdin, r, int64(maxInputSize))
+	}()
+
+	duration := -1.0
+	if withProgress {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			duration = readProgressDuration(progressReader)
+		}()
+	}
+
+	output, outputErr := utils.ReadAllLimit(stdout, maxOutputSize)
+	if outputErr != nil {
+		// stop ffmpeg so the other pipes close
+		cancel()
+	}
+
+	wg.Wait()
+	waitErr := cmd.Wait()
+
+	switch {
+	case errors.Is(inputErr, utils.ErrIOLimitReached):
+		return nil, fmt.Errorf("reading input: %w", inputErr)
+	case outputErr != nil:
+		return nil, fmt.Errorf("reading output: %w", outputErr)
+	case waitErr != nil:
+		return nil, fmt.Errorf("running ffmpeg: %w", commandError(waitErr, stderr))
+	case inputErr != nil:
+		return nil, fmt.Errorf("reading input: %w", inputErr)
+	}
+
+	resampled := &ResampleOutput{
+		Data: output,
+	}
+	if withProgress {
+		if duration < 0 {
+			return nil, ErrFFmpegDurationInvalid
+		}
+		resampled.Duration = duration
+		resampled.Silent = isSilent(parseSilenceDetect(&silenceLog, duration), duration)
+	}
+
+	return resampled, nil
+}
+
+// readProgressDuration reads `-progress` key=value lines until EOF, returning
+// the last reported output time in seconds, or -1 if there was none.
+func readProgressDuration(r io.Reader) float64 {
+	duration := -1.0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok {
+			continue
+		}
+		// N/A before the first frame is written
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || us < 0 {
+			continue
+		}
+		duration = float64(us) / 1e6
+	}
+	// drain so ffmpeg never blocks writing progress
+	io.Copy(io.Discard, r)
+
+	return duration
+}
@@ -0,0 +1,175 @@
+//go:build ignore
+
+// gen_ogg writes the Ogg fixtures for ogg_test.go. The packets aren't real
+// Opus audio, the parser only reads the headers and granule positions.
+//
+//	go run ./media/testdata/gen_ogg.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	flagContinued = 0x01
+	flagBOS       = 0x02
+	flagEOS       = 0x04
+
+	serial  = 0x4f52414e
+	preSkip = 312
+)
+
+var crcTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// page builds an Ogg page. lacing is the segment table, data the segments'
+// bytes.
+func page(flags byte, granule int64, sequence uint32, lacing []byte, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("OggS")
+	b.WriteByte(0)
+	b.WriteByte(flags)
+	binary.Write(&b, binary.LittleEndian, granule)
+	binary.Write(&b, binary.LittleEndian, uint32(serial))
+	binary.Write(&b, binary.LittleEndian, sequence)
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	b.WriteByte(byte(len(lacing)))
+	b.Write(lacing)
+	b.Write(data)
+
+	out := b.Bytes()
+	var crc uint32
+	for _, c := range out {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^c]
+	}
+	binary.LittleEndian.PutUint32(out[22:26], crc)
+	return out
+}
+
+// packetLacing is the segment table for a whole packet of size bytes.
+func packetLacing(size int) []byte {
+	var lacing []byte
+	for ; size >= 255; size -= 255 {
+		lacing = append(lacing, 255)
+	}
+	return append(lacing, byte(size))
+}
+
+func filler(size int, b byte) []byte {
+	return bytes.Repeat([]byte{b}, size)
+}
+
+func opusHead() []byte {
+	var b bytes.Buffer
+	b.WriteString("OpusHead")
+	b.WriteByte(1) // version
+	b.WriteByte(1) // channels
+	binary.Write(&b, binary.LittleEndian, uint16(preSkip))
+	binary.Write(&b, binary.LittleEndian, uint32(48000))
+	binary.Write(&b, binary.LittleEndian, int16(0))
+	b.WriteByte(0) // mapping family
+	return b.Bytes()
+}
+
+func opusTags() []byte {
+	var b bytes.Buffer
+	b.WriteString("OpusTags")
+	binary.Write(&b, binary.LittleEndian, uint32(5))
+	b.WriteString("orange")
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	return b.Bytes()
+}
+
+// opusStream is 2 seconds over several pages, with a 600 byte packet
+// continued from the fourth page onto the fifth, which has no granule.
+func opusStream() []byte {
+	var out []byte
+
+	head := opusHead()
+	out = append(out, page(flagBOS, 0, 0, packetLacing(len(head)), head)...)
+
+	tags := opusTags()
+	out = append(out, page(0, 0, 1, packetLacing(len(tags)), tags)...)
+
+	var lacing, data []byte
+	for i := range 3 {
+		lacing = append(lacing, packetLacing(100)...)
+		data = append(data, filler(100, byte(i))...)
+	}
+	out = append(out, page(0, preSkip+24000, 2, lacing, data)...)
+
+	// the first 510 bytes of the long packet, no packet ends on this page
+	out = append(out, page(0, -1, 3, []byte{255, 255}, filler(510, 3))...)
+
+	lacing = append([]byte{90}, packetLacing(50)...)
+	data = append(filler(90, 3), filler(50, 4)...)
+	out = append(out, page(flagContinued|flagEOS, preSkip+96000, 4, lacing, data)...)
+
+	return out
+}
+
+func vorbisStream() []byte {
+	var b bytes.Buffer
+	b.WriteByte(1)
+	b.WriteString("vorbis")
+	binary.Write(&b, binary.LittleEndian, uint32(0))     // version
+	b.WriteByte(1)                                       // channels
+	binary.Write(&b, binary.LittleEndian, uint32(44100)) // sample rate
+	b.Write(make([]byte, 12))                            // bitrates
+	b.WriteByte(0xb8)                                    // block sizes
+	b.WriteByte(1)                                       // framing
+	id := b.Bytes()
+	return page(flagBOS, 0, 0, packetLacing(len(id)), id)
+}
+
+func wav() []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+16))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, uint16(1))     // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(1))     // channels
+	binary.Write(&b, binary.LittleEndian, uint32(16000)) // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(32000)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(2))     // block align
+	binary.Write(&b, binary.LittleEndian, uint16(16))    // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	b.Write(make([]byte, 16))
+	return b.Bytes()
+}
+
+func main() {
+	dir := filepath.Join("media", "testdata")
+
+	opus := opusStream()
+	fixtures := map[string][]byte{
+		"opus.ogg":           opus,
+		"opus_truncated.ogg": opus[:len(opus)-30],
+		"vorbis.ogg":         vorbisStream(),
+		"not_ogg.wav":        wav(),
+	}
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
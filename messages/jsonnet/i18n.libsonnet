@@ -0,0 +1,27 @@
+local tables = {
+    "en-US": import "locales/en-US.libsonnet",
+    "es": import "locales/es.libsonnet",
+    "pt-BR": import "locales/pt-BR.libsonnet",
+};
+
+local default_locale = "en-US";
+
+{
+    tables: tables,
+    default_locale: default_locale,
+
+    // chain lists the shipped locales to look strings up in, most specific
+    // first, e.g. pt-BR → pt → en-US.
+    chain(locale)::
+        local language = if locale == null || locale == "" then null else std.split(locale, "-")[0];
+        std.foldl(
+            function(chain, l) if l != null && l in tables && !std.member(chain, l) then chain + [l] else chain,
+            [locale, language, default_locale],
+            []
+        ),
+
+    // strings returns the string table for a locale, falling back through
+    // its chain for missing keys.
+    strings(locale)::
+        std.foldl(function(table, l) table + tables[l], std.reverse(self.chain(locale)), {}),
+}
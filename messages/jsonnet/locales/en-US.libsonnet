@@ -0,0 +1,142 @@
+// en-US is the default locale and has every key. Other locales can't add
+// keys, and fall back through their base language to these for missing ones.
+{
+    "error.interaction.title": "Error running interaction",
+    "error.command.title": "Error running command",
+
+    "privacy.uses_cloudflare": "This feature uses Cloudflare for generating transcriptions ([privacy policy](https://www.cloudflare.com/privacypolicy/)), and your voice messages and transcriptions are never stored unless you opt in to transcript history.",
+
+    "settings.title": "Orange user preferences",
+    "settings.asr.name": "ASR",
+    "settings.asr.description": "Enabling ASR will have Orange automatically transcribe your voice messages when you send them in chat, replying with the transcription.",
+    "settings.asr.enable": "Enable ASR",
+    "settings.asr.disable": "Disable ASR",
+    "settings.history.name": "Transcript history",
+    "settings.history.description": "Enabling transcript history will have Orange store the text of your transcriptions (encrypted) so you can browse and search them with </history:%s>. Only transcriptions made after you opt in are stored, and disabling it stops storing new ones. Stored transcripts are kept until you delete your data with </privacy:%s>.",
+    "settings.history.enable": "Enable transcript history",
+    "settings.history.disable": "Disable transcript history",
+    "settings.toggle.asr_enabled.title": "ASR Enabled",
+    "settings.toggle.asr_enabled.description": "When you send a voice message, Orange will reply with an automatically generated transcription of your message.",
+    "settings.toggle.enabled": "Enabled %s",
+    "settings.toggle.disabled": "Disabled %s",
+    "settings.toggle.already_enabled": "%s already enabled",
+    "settings.toggle.already_disabled": "%s already disabled",
+    "settings.toggle.hint": "Use </settings:%s> to update your preferences.",
+
+    "hook.title": "Created webhook",
+    "hook.notice": "You will only see this once. To regenerate, delete the webhook and re-run this command.",
+
+    "asr.progress.title": "Transcribing voice message...",
+    "asr.result.footer": "Transcribed by Orange in %.2f s",
+    "asr.result.low_confidence": "⚠️ Low confidence, this may not be what was said",
+    "asr.no_speech.description": "No speech detected.",
+    "asr.no_speech.footer": "Checked by Orange in %.2f s",
+    "asr.error.title": "Error running transcription",
+    "asr.rate_limited.title": "Voice message not transcribed",
+    "asr.rate_limited.retry_at": "Try again <t:%d:R>.",
+    "asr.rate_limited.retry_later": "Try again later.",
+    "asr.rate_limited.user_rate": "You're sending voice messages faster than Orange can transcribe them.",
+    "asr.rate_limited.guild_rate": "This server is sending voice messages faster than Orange can transcribe them.",
+    "asr.rate_limited.user_quota": "You've reached your daily limit of transcribed audio.",
+    "asr.rate_limited.guild_quota": "This server has reached its daily limit of transcribed audio.",
+
+    // formatted with channel_id, settings_command_id, uses_cloudflare and
+    // guild_name
+    "asr.nudge.content": |||
+        Hi there! It looks like you just sent a voice message in <#%(channel_id)s>.
+
+        To make it easier for people to follow along (and to keep the server accessible to everyone), this bot offers automatic transcriptions (ASR) of voice messages. Opt-in using the button below, and you can run </settings:%(settings_command_id)s> to update your preferences at any time.
+
+        - %(uses_cloudflare)s
+        - If you choose not to enable ASR, we ask that you provide your own transcriptions your voice messages when possible.
+
+        \- Mia
+        -# You're receiving this one-off message because you're a member of **%(guild_name)s**.
+    |||,
+    "asr.nudge.enable": "Enable ASR for future messages",
+
+    "history.title": "Your transcripts",
+    "history.title.search": "Transcripts matching \"%s\"",
+    "history.empty": "You don't have any stored transcripts yet.",
+    "history.empty.search": "No transcripts match your search.",
+    "history.empty.disabled": "You don't have any stored transcripts. Enable transcript history in </settings:%s> to start saving them.",
+    "history.page": "Page %d",
+    "history.previous": "Previous",
+    "history.next": "Next",
+
+    "privacy.menu.title": "Your data",
+    "privacy.menu.description": |||
+        Orange stores your preferences, metadata about transcriptions of your voice messages (message IDs, duration, model and processing time), and the text of your transcriptions if you opted in to transcript history.
+
+        You can download a copy of this data, or delete all of it. Deleting your data also resets your preferences.
+    |||,
+    "privacy.menu.export": "Export my data",
+    "privacy.menu.delete": "Delete my data",
+    "privacy.delete_confirm.title": "Delete your data?",
+    "privacy.delete_confirm.description": |||
+        This permanently deletes your preferences, transcription metadata and transcript history. It can't be undone.
+
+        Orange can also delete the transcription replies it sent to your voice messages.
+    |||,
+    "privacy.delete_confirm.confirm": "Delete data",
+    "privacy.delete_confirm.confirm_all": "Delete data and replies",
+    "privacy.delete_confirm.cancel": "Cancel",
+    "privacy.export.title": "Exported your data",
+    "privacy.export.description": "The attached file contains your preferences, %d transcriptions and %d stored transcripts.",
+    "privacy.delete_result.title": "Deleted your data",
+    "privacy.delete_result.error_title": "Error deleting your data",
+    "privacy.delete_result.summary": "Deleted your preferences, %d transcriptions and %d stored transcripts.",
+    "privacy.delete_result.replies": "Deleted %d replies.",
+    "privacy.delete_result.replies_failed": "Deleted %d replies, %d couldn't be deleted.",
+
+    "guild_settings.title": "Orange server settings",
+    "guild_settings.description": "Limits apply to a rolling 24 hour window. Servers can lower the defaults, but not raise them.",
+    "guild_settings.user_daily_audio": "Daily audio per member",
+    "guild_settings.guild_daily_audio": "Daily audio for the server",
+    "guild_settings.minutes": "%d minutes",
+    "guild_settings.unlimited": "Unlimited",
+    "guild_settings.overridden": "Set for this server (default: %s)",
+    "guild_settings.default": "Default",
+    "guild_settings.low_confidence": "Low confidence transcripts",
+    "guild_settings.low_confidence.flag": "Flagged\n-# Transcripts that look like hallucinations are marked as low confidence",
+    "guild_settings.low_confidence.suppress": "Not posted\n-# Transcripts that look like hallucinations are removed",
+
+    "stats.title": "Orange usage — last %d days",
+    "stats.title.guild": "Orange usage in %s — last %d days",
+    "stats.title.global": "Orange usage (bot-wide) — last %d days",
+    "stats.transcriptions": "Transcriptions",
+    "stats.audio": "Audio",
+    "stats.audio_minutes": "%.1f min",
+    "stats.failure_rate": "Failure rate",
+    "stats.latency": "Latency",
+    "stats.latency.value": "p50 %.2f s · p95 %.2f s",
+    "stats.estimated_cost": "Estimated cost",
+    "stats.by_model": "By model",
+    "stats.by_model.unknown": "unknown",
+    "stats.by_day": "By day (UTC)",
+    "stats.by_guild": "Busiest servers",
+    "stats.row": "%d transcriptions · %.1f min · %.0f%% failed",
+    "stats.none": "None",
+
+    // slash commands, names must be lowercase without spaces
+    "command.settings.name": "settings",
+    "command.settings.description": "Configure Orange's features.",
+    "command.history.name": "history",
+    "command.history.description": "Browse and search your stored transcripts.",
+    "command.history.option.search.description": "Only show transcripts matching these words.",
+    "command.privacy.name": "privacy",
+    "command.privacy.description": "Export or delete the data Orange stores about you.",
+    "command.stats.name": "stats",
+    "command.stats.description": "Show Orange's usage statistics.",
+    "command.stats.option.scope.description": "Whose usage to show.",
+    "command.stats.option.scope.choice.server": "This server",
+    "command.stats.option.scope.choice.global": "Bot-wide (operators only)",
+    "command.stats.option.days.description": "How many days back to include, defaults to %d.",
+    "command.server-settings.name": "server-settings",
+    "command.server-settings.description": "View or change Orange's settings for this server.",
+    "command.server-settings.option.user-daily-minutes.description": "Minutes of audio each member can transcribe per day, 0 resets to the default.",
+    "command.server-settings.option.server-daily-minutes.description": "Minutes of audio the server can transcribe per day, 0 resets to the default.",
+    "command.server-settings.option.low-confidence.description": "What to do with transcripts that look like hallucinations.",
+    "command.server-settings.option.low-confidence.choice.flag": "Flag them",
+    "command.server-settings.option.low-confidence.choice.suppress": "Don't reply",
+}
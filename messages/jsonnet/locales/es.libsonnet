@@ -0,0 +1,137 @@
+{
+    "error.interaction.title": "Error al ejecutar la interacción",
+    "error.command.title": "Error al ejecutar el comando",
+
+    "privacy.uses_cloudflare": "Esta función usa Cloudflare para generar las transcripciones ([política de privacidad](https://www.cloudflare.com/privacypolicy/)), y tus mensajes de voz y transcripciones nunca se guardan a menos que actives el historial de transcripciones.",
+
+    "settings.title": "Preferencias de usuario de Orange",
+    "settings.asr.name": "ASR",
+    "settings.asr.description": "Al activar ASR, Orange transcribirá automáticamente tus mensajes de voz cuando los envíes en el chat y responderá con la transcripción.",
+    "settings.asr.enable": "Activar ASR",
+    "settings.asr.disable": "Desactivar ASR",
+    "settings.history.name": "Historial de transcripciones",
+    "settings.history.description": "Al activar el historial de transcripciones, Orange guardará el texto de tus transcripciones (cifrado) para que puedas explorarlas y buscarlas con </history:%s>. Solo se guardan las transcripciones hechas después de activarlo, y al desactivarlo se dejan de guardar las nuevas. Las transcripciones guardadas se conservan hasta que elimines tus datos con </privacy:%s>.",
+    "settings.history.enable": "Activar el historial de transcripciones",
+    "settings.history.disable": "Desactivar el historial de transcripciones",
+    "settings.toggle.asr_enabled.title": "ASR activado",
+    "settings.toggle.asr_enabled.description": "Cuando envíes un mensaje de voz, Orange responderá con una transcripción generada automáticamente de tu mensaje.",
+    "settings.toggle.enabled": "%s activado",
+    "settings.toggle.disabled": "%s desactivado",
+    "settings.toggle.already_enabled": "%s ya estaba activado",
+    "settings.toggle.already_disabled": "%s ya estaba desactivado",
+    "settings.toggle.hint": "Usa </settings:%s> para cambiar tus preferencias.",
+
+    "hook.title": "Webhook creado",
+    "hook.notice": "Solo verás esto una vez. Para regenerarlo, elimina el webhook y vuelve a ejecutar este comando.",
+
+    "asr.progress.title": "Transcribiendo mensaje de voz...",
+    "asr.result.footer": "Transcrito por Orange en %.2f s",
+    "asr.result.low_confidence": "⚠️ Baja confianza, puede que esto no sea lo que se dijo",
+    "asr.no_speech.description": "No se detectó voz.",
+    "asr.no_speech.footer": "Revisado por Orange en %.2f s",
+    "asr.error.title": "Error al transcribir",
+    "asr.rate_limited.title": "Mensaje de voz no transcrito",
+    "asr.rate_limited.retry_at": "Inténtalo de nuevo <t:%d:R>.",
+    "asr.rate_limited.retry_later": "Inténtalo de nuevo más tarde.",
+    "asr.rate_limited.user_rate": "Estás enviando mensajes de voz más rápido de lo que Orange puede transcribirlos.",
+    "asr.rate_limited.guild_rate": "Este servidor está enviando mensajes de voz más rápido de lo que Orange puede transcribirlos.",
+    "asr.rate_limited.user_quota": "Has alcanzado tu límite diario de audio transcrito.",
+    "asr.rate_limited.guild_quota": "Este servidor ha alcanzado su límite diario de audio transcrito.",
+
+    "asr.nudge.content": |||
+        ¡Hola! Parece que acabas de enviar un mensaje de voz en <#%(channel_id)s>.
+
+        Para que a todos les resulte más fácil seguir la conversación (y para que el servidor sea accesible para todos), este bot ofrece transcripciones automáticas (ASR) de los mensajes de voz. Actívalas con el botón de abajo, y puedes usar </settings:%(settings_command_id)s> para cambiar tus preferencias en cualquier momento.
+
+        - %(uses_cloudflare)s
+        - Si decides no activar ASR, te pedimos que incluyas tus propias transcripciones de tus mensajes de voz cuando sea posible.
+
+        \- Mia
+        -# Recibes este mensaje único porque eres miembro de **%(guild_name)s**.
+    |||,
+    "asr.nudge.enable": "Activar ASR para futuros mensajes",
+
+    "history.title": "Tus transcripciones",
+    "history.title.search": "Transcripciones que coinciden con \"%s\"",
+    "history.empty": "Todavía no tienes transcripciones guardadas.",
+    "history.empty.search": "Ninguna transcripción coincide con tu búsqueda.",
+    "history.empty.disabled": "No tienes transcripciones guardadas. Activa el historial de transcripciones en </settings:%s> para empezar a guardarlas.",
+    "history.page": "Página %d",
+    "history.previous": "Anterior",
+    "history.next": "Siguiente",
+
+    "privacy.menu.title": "Tus datos",
+    "privacy.menu.description": |||
+        Orange guarda tus preferencias, metadatos sobre las transcripciones de tus mensajes de voz (IDs de mensajes, duración, modelo y tiempo de procesamiento) y el texto de tus transcripciones si activaste el historial de transcripciones.
+
+        Puedes descargar una copia de estos datos o eliminarlos todos. Eliminar tus datos también restablece tus preferencias.
+    |||,
+    "privacy.menu.export": "Exportar mis datos",
+    "privacy.menu.delete": "Eliminar mis datos",
+    "privacy.delete_confirm.title": "¿Eliminar tus datos?",
+    "privacy.delete_confirm.description": |||
+        Esto elimina permanentemente tus preferencias, los metadatos de tus transcripciones y tu historial de transcripciones. No se puede deshacer.
+
+        Orange también puede eliminar las respuestas con transcripciones que envió a tus mensajes de voz.
+    |||,
+    "privacy.delete_confirm.confirm": "Eliminar datos",
+    "privacy.delete_confirm.confirm_all": "Eliminar datos y respuestas",
+    "privacy.delete_confirm.cancel": "Cancelar",
+    "privacy.export.title": "Datos exportados",
+    "privacy.export.description": "El archivo adjunto contiene tus preferencias, %d transcripciones y %d transcripciones guardadas.",
+    "privacy.delete_result.title": "Datos eliminados",
+    "privacy.delete_result.error_title": "Error al eliminar tus datos",
+    "privacy.delete_result.summary": "Se eliminaron tus preferencias, %d transcripciones y %d transcripciones guardadas.",
+    "privacy.delete_result.replies": "Se eliminaron %d respuestas.",
+    "privacy.delete_result.replies_failed": "Se eliminaron %d respuestas, %d no se pudieron eliminar.",
+
+    "guild_settings.title": "Ajustes de Orange del servidor",
+    "guild_settings.description": "Los límites se aplican a un periodo continuo de 24 horas. Los servidores pueden reducir los valores predeterminados, pero no aumentarlos.",
+    "guild_settings.user_daily_audio": "Audio diario por miembro",
+    "guild_settings.guild_daily_audio": "Audio diario del servidor",
+    "guild_settings.minutes": "%d minutos",
+    "guild_settings.unlimited": "Ilimitado",
+    "guild_settings.overridden": "Configurado para este servidor (predeterminado: %s)",
+    "guild_settings.default": "Predeterminado",
+    "guild_settings.low_confidence": "Transcripciones de baja confianza",
+    "guild_settings.low_confidence.flag": "Marcadas\n-# Las transcripciones que parecen alucinaciones se marcan como de baja confianza",
+    "guild_settings.low_confidence.suppress": "No se publican\n-# Las transcripciones que parecen alucinaciones se eliminan",
+
+    "stats.title": "Uso de Orange — últimos %d días",
+    "stats.title.guild": "Uso de Orange en %s — últimos %d días",
+    "stats.title.global": "Uso de Orange (todo el bot) — últimos %d días",
+    "stats.transcriptions": "Transcripciones",
+    "stats.audio": "Audio",
+    "stats.audio_minutes": "%.1f min",
+    "stats.failure_rate": "Tasa de errores",
+    "stats.latency": "Latencia",
+    "stats.latency.value": "p50 %.2f s · p95 %.2f s",
+    "stats.estimated_cost": "Costo estimado",
+    "stats.by_model": "Por modelo",
+    "stats.by_model.unknown": "desconocido",
+    "stats.by_day": "Por día (UTC)",
+    "stats.by_guild": "Servidores más activos",
+    "stats.row": "%d transcripciones · %.1f min · %.0f%% con errores",
+    "stats.none": "Ninguno",
+
+    "command.settings.name": "ajustes",
+    "command.settings.description": "Configura las funciones de Orange.",
+    "command.history.name": "historial",
+    "command.history.description": "Explora y busca tus transcripciones guardadas.",
+    "command.history.option.search.description": "Mostrar solo las transcripciones que contengan estas palabras.",
+    "command.privacy.name": "privacidad",
+    "command.privacy.description": "Exporta o elimina los datos que Orange guarda sobre ti.",
+    "command.stats.name": "estadisticas",
+    "command.stats.description": "Muestra las estadísticas de uso de Orange.",
+    "command.stats.option.scope.description": "De quién mostrar el uso.",
+    "command.stats.option.scope.choice.server": "Este servidor",
+    "command.stats.option.scope.choice.global": "Todo el bot (solo operadores)",
+    "command.stats.option.days.description": "Cuántos días hacia atrás incluir, %d de forma predeterminada.",
+    "command.server-settings.name": "ajustes-del-servidor",
+    "command.server-settings.description": "Consulta o cambia los ajustes de Orange para este servidor.",
+    "command.server-settings.option.user-daily-minutes.description": "Minutos de audio que cada miembro puede transcribir al día, 0 restablece el valor predeterminado.",
+    "command.server-settings.option.server-daily-minutes.description": "Minutos de audio que el servidor puede transcribir al día, 0 restablece el valor predeterminado.",
+    "command.server-settings.option.low-confidence.description": "Qué hacer con las transcripciones que parecen alucinaciones.",
+    "command.server-settings.option.low-confidence.choice.flag": "Marcarlas",
+    "command.server-settings.option.low-confidence.choice.suppress": "No responder",
+}
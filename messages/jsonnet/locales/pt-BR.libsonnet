@@ -0,0 +1,137 @@
+{
+    "error.interaction.title": "Erro ao executar a interação",
+    "error.command.title": "Erro ao executar o comando",
+
+    "privacy.uses_cloudflare": "Este recurso usa o Cloudflare para gerar as transcrições ([política de privacidade](https://www.cloudflare.com/privacypolicy/)), e suas mensagens de voz e transcrições nunca são armazenadas, a menos que você ative o histórico de transcrições.",
+
+    "settings.title": "Preferências de usuário do Orange",
+    "settings.asr.name": "ASR",
+    "settings.asr.description": "Ativar o ASR faz o Orange transcrever automaticamente suas mensagens de voz quando você as envia no chat, respondendo com a transcrição.",
+    "settings.asr.enable": "Ativar ASR",
+    "settings.asr.disable": "Desativar ASR",
+    "settings.history.name": "Histórico de transcrições",
+    "settings.history.description": "Ativar o histórico de transcrições faz o Orange armazenar o texto das suas transcrições (criptografado) para que você possa navegar e pesquisar nelas com </history:%s>. Só são armazenadas as transcrições feitas depois de ativá-lo, e desativá-lo para de armazenar novas. As transcrições armazenadas são mantidas até você apagar seus dados com </privacy:%s>.",
+    "settings.history.enable": "Ativar histórico de transcrições",
+    "settings.history.disable": "Desativar histórico de transcrições",
+    "settings.toggle.asr_enabled.title": "ASR ativado",
+    "settings.toggle.asr_enabled.description": "Quando você enviar uma mensagem de voz, o Orange responderá com uma transcrição gerada automaticamente da sua mensagem.",
+    "settings.toggle.enabled": "%s ativado",
+    "settings.toggle.disabled": "%s desativado",
+    "settings.toggle.already_enabled": "%s já estava ativado",
+    "settings.toggle.already_disabled": "%s já estava desativado",
+    "settings.toggle.hint": "Use </settings:%s> para alterar suas preferências.",
+
+    "hook.title": "Webhook criado",
+    "hook.notice": "Você só verá isto uma vez. Para gerar outro, apague o webhook e execute este comando novamente.",
+
+    "asr.progress.title": "Transcrevendo mensagem de voz...",
+    "asr.result.footer": "Transcrito pelo Orange em %.2f s",
+    "asr.result.low_confidence": "⚠️ Baixa confiança, isto pode não ser o que foi dito",
+    "asr.no_speech.description": "Nenhuma fala detectada.",
+    "asr.no_speech.footer": "Verificado pelo Orange em %.2f s",
+    "asr.error.title": "Erro ao transcrever",
+    "asr.rate_limited.title": "Mensagem de voz não transcrita",
+    "asr.rate_limited.retry_at": "Tente novamente <t:%d:R>.",
+    "asr.rate_limited.retry_later": "Tente novamente mais tarde.",
+    "asr.rate_limited.user_rate": "Você está enviando mensagens de voz mais rápido do que o Orange consegue transcrevê-las.",
+    "asr.rate_limited.guild_rate": "Este servidor está enviando mensagens de voz mais rápido do que o Orange consegue transcrevê-las.",
+    "asr.rate_limited.user_quota": "Você atingiu seu limite diário de áudio transcrito.",
+    "asr.rate_limited.guild_quota": "Este servidor atingiu seu limite diário de áudio transcrito.",
+
+    "asr.nudge.content": |||
+        Olá! Parece que você acabou de enviar uma mensagem de voz em <#%(channel_id)s>.
+
+        Para facilitar que todos acompanhem a conversa (e manter o servidor acessível a todos), este bot oferece transcrições automáticas (ASR) de mensagens de voz. Ative usando o botão abaixo, e você pode usar </settings:%(settings_command_id)s> para alterar suas preferências a qualquer momento.
+
+        - %(uses_cloudflare)s
+        - Se você preferir não ativar o ASR, pedimos que você escreva suas próprias transcrições das suas mensagens de voz sempre que possível.
+
+        \- Mia
+        -# Você está recebendo esta mensagem única porque é membro de **%(guild_name)s**.
+    |||,
+    "asr.nudge.enable": "Ativar ASR para mensagens futuras",
+
+    "history.title": "Suas transcrições",
+    "history.title.search": "Transcrições com \"%s\"",
+    "history.empty": "Você ainda não tem transcrições armazenadas.",
+    "history.empty.search": "Nenhuma transcrição corresponde à sua pesquisa.",
+    "history.empty.disabled": "Você não tem transcrições armazenadas. Ative o histórico de transcrições em </settings:%s> para começar a salvá-las.",
+    "history.page": "Página %d",
+    "history.previous": "Anterior",
+    "history.next": "Próxima",
+
+    "privacy.menu.title": "Seus dados",
+    "privacy.menu.description": |||
+        O Orange armazena suas preferências, metadados sobre as transcrições das suas mensagens de voz (IDs das mensagens, duração, modelo e tempo de processamento) e o texto das suas transcrições se você ativou o histórico de transcrições.
+
+        Você pode baixar uma cópia desses dados ou apagar todos eles. Apagar seus dados também redefine suas preferências.
+    |||,
+    "privacy.menu.export": "Exportar meus dados",
+    "privacy.menu.delete": "Apagar meus dados",
+    "privacy.delete_confirm.title": "Apagar seus dados?",
+    "privacy.delete_confirm.description": |||
+        Isto apaga permanentemente suas preferências, os metadados das suas transcrições e seu histórico de transcrições. Não é possível desfazer.
+
+        O Orange também pode apagar as respostas com transcrições que enviou às suas mensagens de voz.
+    |||,
+    "privacy.delete_confirm.confirm": "Apagar dados",
+    "privacy.delete_confirm.confirm_all": "Apagar dados e respostas",
+    "privacy.delete_confirm.cancel": "Cancelar",
+    "privacy.export.title": "Dados exportados",
+    "privacy.export.description": "O arquivo anexado contém suas preferências, %d transcrições e %d transcrições armazenadas.",
+    "privacy.delete_result.title": "Dados apagados",
+    "privacy.delete_result.error_title": "Erro ao apagar seus dados",
+    "privacy.delete_result.summary": "Suas preferências, %d transcrições e %d transcrições armazenadas foram apagadas.",
+    "privacy.delete_result.replies": "%d respostas foram apagadas.",
+    "privacy.delete_result.replies_failed": "%d respostas foram apagadas, %d não puderam ser apagadas.",
+
+    "guild_settings.title": "Configurações do Orange no servidor",
+    "guild_settings.description": "Os limites valem para uma janela contínua de 24 horas. Os servidores podem reduzir os padrões, mas não aumentá-los.",
+    "guild_settings.user_daily_audio": "Áudio diário por membro",
+    "guild_settings.guild_daily_audio": "Áudio diário do servidor",
+    "guild_settings.minutes": "%d minutos",
+    "guild_settings.unlimited": "Ilimitado",
+    "guild_settings.overridden": "Definido para este servidor (padrão: %s)",
+    "guild_settings.default": "Padrão",
+    "guild_settings.low_confidence": "Transcrições de baixa confiança",
+    "guild_settings.low_confidence.flag": "Marcadas\n-# Transcrições que parecem alucinações são marcadas como de baixa confiança",
+    "guild_settings.low_confidence.suppress": "Não publicadas\n-# Transcrições que parecem alucinações são removidas",
+
+    "stats.title": "Uso do Orange — últimos %d dias",
+    "stats.title.guild": "Uso do Orange em %s — últimos %d dias",
+    "stats.title.global": "Uso do Orange (todo o bot) — últimos %d dias",
+    "stats.transcriptions": "Transcrições",
+    "stats.audio": "Áudio",
+    "stats.audio_minutes": "%.1f min",
+    "stats.failure_rate": "Taxa de falhas",
+    "stats.latency": "Latência",
+    "stats.latency.value": "p50 %.2f s · p95 %.2f s",
+    "stats.estimated_cost": "Custo estimado",
+    "stats.by_model": "Por modelo",
+    "stats.by_model.unknown": "desconhecido",
+    "stats.by_day": "Por dia (UTC)",
+    "stats.by_guild": "Servidores mais ativos",
+    "stats.row": "%d transcrições · %.1f min · %.0f%% com falha",
+    "stats.none": "Nenhum",
+
+    "command.settings.name": "configuracoes",
+    "command.settings.description": "Configure os recursos do Orange.",
+    "command.history.name": "historico",
+    "command.history.description": "Navegue e pesquise suas transcrições salvas.",
+    "command.history.option.search.description": "Mostrar apenas transcrições com estas palavras.",
+    "command.privacy.name": "privacidade",
+    "command.privacy.description": "Exporte ou apague os dados que o Orange guarda sobre você.",
+    "command.stats.name": "estatisticas",
+    "command.stats.description": "Mostre as estatísticas de uso do Orange.",
+    "command.stats.option.scope.description": "De quem mostrar o uso.",
+    "command.stats.option.scope.choice.server": "Este servidor",
+    "command.stats.option.scope.choice.global": "Todo o bot (apenas operadores)",
+    "command.stats.option.days.description": "Quantos dias para trás incluir, o padrão é %d.",
+    "command.server-settings.name": "configuracoes-do-servidor",
+    "command.server-settings.description": "Veja ou altere as configurações do Orange para este servidor.",
+    "command.server-settings.option.user-daily-minutes.description": "Minutos de áudio que cada membro pode transcrever por dia, 0 volta ao padrão.",
+    "command.server-settings.option.server-daily-minutes.description": "Minutos de áudio que o servidor pode transcrever por dia, 0 volta ao padrão.",
+    "command.server-settings.option.low-confidence.description": "O que fazer com transcrições que parecem alucinações.",
+    "command.server-settings.option.low-confidence.choice.flag": "Marcá-las",
+    "command.server-settings.option.low-confidence.choice.suppress": "Não responder",
+}
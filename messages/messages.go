@@ -5,23 +5,151 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"runtime"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
 )
 
 //go:embed jsonnet/*
 var messages embed.FS
 
+// MessageProvider renders messages from Jsonnet templates. It's safe for
+// concurrent use: jsonnet.VM isn't, so each render borrows a VM from a pool.
 type MessageProvider struct {
-	vm *jsonnet.VM
+	log *zap.Logger
+
+	poolSize int
+	// templateDir is layered over the embedded templates if set
+	templateDir string
+
+	validators []Validator
+
+	pool atomic.Pointer[vmPool]
 }
 
-func NewMessageProvider() (*MessageProvider, error) {
+// Renderer renders messages from one version of the templates.
+type Renderer interface {
+	ExecuteMessage(messageName string, data any) (string, error)
+	// MessageKeys lists the messages in index.jsonnet
+	MessageKeys() ([]string, error)
+	// Locales lists the locales with a string table
+	Locales() ([]string, error)
+}
+
+// Validator checks templates before they're used. If it returns an error,
+// loading fails, or a reload keeps the current templates.
+type Validator func(r Renderer) error
+
+type MessageProviderOptions func(*MessageProvider)
+
+// WithPoolSize sets how many VMs are kept, which is how many messages can
+// render at once. Defaults to GOMAXPROCS.
+func WithPoolSize(size int) MessageProviderOptions {
+	return func(m *MessageProvider) {
+		if size > 0 {
+			m.poolSize = size
+		}
+	}
+}
+
+// WithTemplateDir layers templates from dir over the embedded ones, files in
+// dir replacing embedded files with the same path.
+func WithTemplateDir(dir string) MessageProviderOptions {
+	return func(m *MessageProvider) {
+		m.templateDir = dir
+	}
+}
+
+// WithValidator adds a check run every time templates are loaded, on top of
+// making sure they evaluate.
+func WithValidator(validator Validator) MessageProviderOptions {
+	return func(m *MessageProvider) {
+		m.validators = append(m.validators, validator)
+	}
+}
+
+func WithLogger(parentLogger *zap.Logger) MessageProviderOptions {
+	return func(m *MessageProvider) {
+		m.log = parentLogger.Named("messages")
+	}
+}
+
+type vmPool struct {
+	vms chan *jsonnet.VM
+}
+
+// validateTemplates evaluates every template file, since imports are lazy
+// and errors would otherwise only show up when a message uses them.
+func validateTemplates(vm *jsonnet.VM, imports map[string]jsonnet.Contents) error {
+	for path := range imports {
+		_, err := vm.EvaluateAnonymousSnippet("validate", fmt.Sprintf("std.type(import %q)", path))
+		if err != nil {
+			return fmt.Errorf("evaluating %s: %w", path, err)
+		}
+	}
+
+	_, err := vm.EvaluateAnonymousSnippet("validate", "std.assertEqual(std.type(import 'index.jsonnet'), 'object')")
+	if err != nil {
+		return fmt.Errorf("index isn't an object: %w", err)
+	}
+
+	return nil
+}
+
+func newVMPool(imports map[string]jsonnet.Contents, size int) (*vmPool, error) {
+	p := &vmPool{
+		vms: make(chan *jsonnet.VM, size),
+	}
+
+	for i := range size {
+		vm := jsonnet.MakeVM()
+		// the importer is only read from, so VMs can share its contents
+		vm.Importer(&jsonnet.MemoryImporter{
+			Data: imports,
+		})
+
+		if i == 0 {
+			err := validateTemplates(vm, imports)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// also warms the VM's import cache
+		_, _, err := vm.ImportData("anonymous", "index.jsonnet")
+		if err != nil {
+			return nil, fmt.Errorf("importing index: %w", err)
+		}
+
+		p.vms <- vm
+	}
+
+	return p, nil
+}
+
+func NewMessageProvider(options ...MessageProviderOptions) (*MessageProvider, error) {
 	m := &MessageProvider{
-		vm: jsonnet.MakeVM(),
+		log:      zap.NewNop(),
+		poolSize: runtime.GOMAXPROCS(0),
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	err := m.load()
+	if err != nil {
+		return nil, err
 	}
 
+	return m, nil
+}
+
+// readTemplates reads the embedded templates, then the template directory
+// over them.
+func (m *MessageProvider) readTemplates() (map[string]jsonnet.Contents, error) {
 	imports := make(map[string]jsonnet.Contents)
 	fs.WalkDir(messages, ".", func(path string, d fs.DirEntry, err error) error {
 		if d != nil && !d.IsDir() {
@@ -31,33 +159,130 @@ func NewMessageProvider() (*MessageProvider, error) {
 		return nil
 	})
 
-	m.vm.Importer(&jsonnet.MemoryImporter{
-		Data: imports,
-	})
+	if m.templateDir != "" {
+		err := readTemplateDir(m.templateDir, imports)
+		if err != nil {
+			return nil, fmt.Errorf("reading template dir: %w", err)
+		}
+	}
+
+	return imports, nil
+}
 
-	_, _, err := m.vm.ImportData("anonymous", "index.jsonnet")
+// load builds a new VM pool from the templates and swaps it in, keeping the
+// current pool if the templates don't import.
+func (m *MessageProvider) load() error {
+	imports, err := m.readTemplates()
 	if err != nil {
-		return nil, fmt.Errorf("importing index: %w", err)
+		return err
 	}
 
-	return m, nil
+	pool, err := newVMPool(imports, m.poolSize)
+	if err != nil {
+		return err
+	}
+
+	for _, validator := range m.validators {
+		err = validator(pool)
+		if err != nil {
+			return fmt.Errorf("validating templates: %w", err)
+		}
+	}
+
+	m.pool.Store(pool)
+	return nil
 }
 
-func (m *MessageProvider) ExecuteMessage(messageName string, data any) (string, error) {
-	m.vm.TLAVar("message_key", messageName)
+// evaluate runs snippet on a VM from the pool, with setTLAs setting its top
+// level arguments.
+func (p *vmPool) evaluate(snippet string, setTLAs func(vm *jsonnet.VM)) (string, error) {
+	vm := <-p.vms
+	defer func() {
+		vm.TLAReset()
+		p.vms <- vm
+	}()
 
+	setTLAs(vm)
+
+	return vm.EvaluateAnonymousSnippet("anonymous", snippet)
+}
+
+// evaluateList evaluates a snippet that returns a list of strings.
+func (p *vmPool) evaluateList(snippet string) ([]string, error) {
+	jsonOut, err := p.evaluate(snippet, func(vm *jsonnet.VM) {})
+	if err != nil {
+		return nil, fmt.Errorf("evaluating jsonnet: %w", err)
+	}
+
+	var list []string
+	err = json.Unmarshal([]byte(jsonOut), &list)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling list: %w", err)
+	}
+
+	return list, nil
+}
+
+func (p *vmPool) ExecuteMessage(messageName string, data any) (string, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return "", fmt.Errorf("marshaling data: %w", err)
 	}
-	m.vm.TLACode("data", string(jsonData))
-
-	defer m.vm.TLAReset()
 
-	jsonOut, err := m.vm.EvaluateAnonymousSnippet("anonymous", "function(message_key, data) (import 'index.jsonnet')[message_key](data)")
+	jsonOut, err := p.evaluate("function(message_key, data) (import 'index.jsonnet')[message_key](data)", func(vm *jsonnet.VM) {
+		vm.TLAVar("message_key", messageName)
+		vm.TLACode("data", string(jsonData))
+	})
 	if err != nil {
 		return "", fmt.Errorf("evaluating jsonnet: %w", err)
 	}
 
 	return jsonOut, nil
 }
+
+func (p *vmPool) MessageKeys() ([]string, error) {
+	return p.evaluateList("std.objectFields(import 'index.jsonnet')")
+}
+
+func (p *vmPool) Locales() ([]string, error) {
+	return p.evaluateList("std.objectFields((import 'i18n.libsonnet').tables)")
+}
+
+func (p *vmPool) Strings(locale string) (map[string]string, error) {
+	jsonOut, err := p.evaluate("function(locale) (import 'i18n.libsonnet').strings(locale)", func(vm *jsonnet.VM) {
+		vm.TLAVar("locale", locale)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("evaluating jsonnet: %w", err)
+	}
+
+	var table map[string]string
+	err = json.Unmarshal([]byte(jsonOut), &table)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling strings: %w", err)
+	}
+
+	return table, nil
+}
+
+// the provider's methods use the current pool, and VMs go back to the pool
+// they came from, so a reload doesn't mix them
+
+func (m *MessageProvider) ExecuteMessage(messageName string, data any) (string, error) {
+	return m.pool.Load().ExecuteMessage(messageName, data)
+}
+
+func (m *MessageProvider) MessageKeys() ([]string, error) {
+	return m.pool.Load().MessageKeys()
+}
+
+func (m *MessageProvider) Locales() ([]string, error) {
+	return m.pool.Load().Locales()
+}
+
+// Strings returns the string table for a locale, such as "pt-BR", with keys
+// it has no translation for falling back to more general locales and
+// finally en-US.
+func (m *MessageProvider) Strings(locale string) (map[string]string, error) {
+	return m.pool.Load().Strings(locale)
+}
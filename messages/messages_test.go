@@ -0,0 +1,244 @@
+package messages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-jsonnet"
+)
+
+func newTestProvider(tb testing.TB, options ...MessageProviderOptions) *MessageProvider {
+	tb.Helper()
+	m, err := NewMessageProvider(options...)
+	if err != nil {
+		tb.Fatalf("creating message provider: %v", err)
+	}
+	return m
+}
+
+func TestExecuteMessageConcurrent(t *testing.T) {
+	m := newTestProvider(t, WithPoolSize(4))
+
+	data := map[string]any{"locale": "pt-BR"}
+	want, err := m.ExecuteMessage("asr_progress", data)
+	if err != nil {
+		t.Fatalf("rendering: %v", err)
+	}
+
+	const (
+		renderers = 16
+		renders   = 50
+	)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, renderers*renders)
+
+	stop := make(chan struct{})
+	reloaded := make(chan struct{})
+	// reloads swap the pool while messages render from the old one
+	go func() {
+		defer close(reloaded)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := m.load(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	for range renderers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range renders {
+				got, err := m.ExecuteMessage("asr_progress", data)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if got != want {
+					t.Errorf("got %s, want %s", got, want)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	<-reloaded
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkExecuteMessage(b *testing.B) {
+	m := newTestProvider(b)
+	data := map[string]any{"locale": "pt-BR"}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := m.ExecuteMessage("asr_progress", data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestLocaleKeys checks the string tables against the default locale's. No
+// table can have keys it doesn't, and locales nothing else falls back to must
+// translate every key between the tables in their chain, so only base
+// language tables like pt under pt-BR can be partial.
+func TestLocaleKeys(t *testing.T) {
+	pool := newTestProvider(t).pool.Load()
+
+	jsonOut, err := pool.evaluate(`
+		local i18n = import 'i18n.libsonnet';
+		{
+			default_locale: i18n.default_locale,
+			keys: { [locale]: std.objectFields(i18n.tables[locale]) for locale in std.objectFields(i18n.tables) },
+			chains: { [locale]: i18n.chain(locale) for locale in std.objectFields(i18n.tables) },
+		}
+	`, func(vm *jsonnet.VM) {})
+	if err != nil {
+		t.Fatalf("evaluating locales: %v", err)
+	}
+
+	var i18n struct {
+		DefaultLocale string              `json:"default_locale"`
+		Keys          map[string][]string `json:"keys"`
+		Chains        map[string][]string `json:"chains"`
+	}
+	err = json.Unmarshal([]byte(jsonOut), &i18n)
+	if err != nil {
+		t.Fatalf("unmarshaling locales: %v", err)
+	}
+
+	defaultKeys := i18n.Keys[i18n.DefaultLocale]
+	if len(defaultKeys) == 0 {
+		t.Fatalf("default locale %s has no strings", i18n.DefaultLocale)
+	}
+
+	for locale, keys := range i18n.Keys {
+		t.Run(locale, func(t *testing.T) {
+			for _, key := range keys {
+				if !slices.Contains(defaultKeys, key) {
+					t.Errorf("unknown key %s", key)
+				}
+			}
+
+			for other := range i18n.Keys {
+				if strings.HasPrefix(other, locale+"-") {
+					// other locales fall back to this one
+					return
+				}
+			}
+
+			translated := map[string]bool{}
+			for _, l := range i18n.Chains[locale] {
+				if l == i18n.DefaultLocale && locale != i18n.DefaultLocale {
+					continue
+				}
+				for _, key := range i18n.Keys[l] {
+					translated[key] = true
+				}
+			}
+			for _, key := range defaultKeys {
+				if !translated[key] {
+					t.Errorf("missing key %s", key)
+				}
+			}
+		})
+	}
+}
+
+// writeConfigMapVersion writes colors.libsonnet with the given orange into a
+// new versioned directory, the way a ConfigMap volume stores its files.
+func writeConfigMapVersion(t *testing.T, dir, version, orange string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, version), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	colors := fmt.Sprintf(`{ orange: std.parseHex(%q), yellow: 0, red: 0, green: 0 }`, orange)
+	if err := os.WriteFile(filepath.Join(dir, version, "colors.libsonnet"), []byte(colors), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMapVersion(t, dir, "..v1", "000001")
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "colors.libsonnet"), filepath.Join(dir, "colors.libsonnet")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestProvider(t, WithTemplateDir(dir))
+	color := func() float64 {
+		t.Helper()
+		rendered, err := m.ExecuteMessage("asr_progress", map[string]any{"locale": "en-US"})
+		if err != nil {
+			t.Fatalf("rendering: %v", err)
+		}
+		var message struct {
+			Embeds []struct {
+				Color float64 `json:"color"`
+			} `json:"embeds"`
+		}
+		if err := json.Unmarshal([]byte(rendered), &message); err != nil {
+			t.Fatalf("decoding: %v", err)
+		}
+		if len(message.Embeds) == 0 {
+			t.Fatalf("no embeds in %s", rendered)
+		}
+		return message.Embeds[0].Color
+	}
+	if got := color(); got != 1 {
+		t.Fatalf("color is %g before the update, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan error, 1)
+	go func() { watched <- m.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-watched
+	})
+	// give the watcher time to start before updating
+	time.Sleep(100 * time.Millisecond)
+
+	// the kubelet swaps the data link in one rename, leaving the file symlinks
+	// untouched
+	writeConfigMapVersion(t, dir, "..v2", "000002")
+	if err := os.Symlink("..v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for color() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("templates weren't reloaded after the ConfigMap update")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
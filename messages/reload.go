@@ -0,0 +1,130 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/go-jsonnet"
+	"go.uber.org/zap"
+)
+
+// how long to wait for more changes before reloading, editors often write
+// files in several steps
+const reloadDebounce = 250 * time.Millisecond
+
+// Kubernetes ConfigMap volumes keep the files in a timestamped "..<time>"
+// directory and update them by swapping the "..data" symlink to a new one, so
+// the visible files are symlinks that never change themselves.
+const configMapDataLink = "..data"
+
+// isConfigMapInternal reports whether a directory entry is one of the hidden
+// ".." entries of a ConfigMap volume, rather than a template.
+func isConfigMapInternal(name string) bool {
+	return strings.HasPrefix(name, "..")
+}
+
+func isTemplateFile(path string) bool {
+	return strings.HasSuffix(path, ".jsonnet") || strings.HasSuffix(path, ".libsonnet")
+}
+
+// readTemplateDir reads the Jsonnet files in dir into imports, keyed by their
+// path relative to dir.
+func readTemplateDir(dir string, imports map[string]jsonnet.Contents) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && isConfigMapInternal(d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !isTemplateFile(path) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("relative path of %s: %w", path, err)
+		}
+
+		imports[filepath.ToSlash(relative)] = jsonnet.MakeContentsRaw(content)
+		return nil
+	})
+}
+
+// Watch reloads templates when files in the template directory change until
+// ctx is done. Templates that fail to load are logged, and the last good
+// version is kept. It returns immediately without a template directory.
+func (m *MessageProvider) Watch(ctx context.Context) error {
+	if m.templateDir == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// fsnotify isn't recursive
+	err = filepath.WalkDir(m.templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("watching template dir: %w", err)
+	}
+
+	m.log.With(zap.String("dir", m.templateDir)).Info("watching templates")
+
+	debounce := time.NewTimer(0)
+	<-debounce.C
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watcher.Add(event.Name)
+				}
+			}
+			if isTemplateFile(event.Name) || filepath.Base(event.Name) == configMapDataLink {
+				debounce.Reset(reloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			m.log.Warn("template watcher error", zap.Error(err))
+		case <-debounce.C:
+			err := m.load()
+			if err != nil {
+				m.log.Error("failed to reload templates, keeping the last good version", zap.Error(err))
+				continue
+			}
+			m.log.Info("reloaded templates")
+		}
+	}
+}
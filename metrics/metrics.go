@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "orange"
+
+type SkipReason string
+
+const (
+	SkipReasonTooBig      = SkipReason("too_big")
+	SkipReasonTooLong     = SkipReason("too_long")
+	SkipReasonNotOptedIn  = SkipReason("not_opted_in")
+	SkipReasonRateLimited = SkipReason("rate_limited")
+)
+
+type Stage string
+
+const (
+	// StageDownloadResponse is the time until the attachment's response
+	// headers arrive, the body is streamed into ffmpeg
+	StageDownloadResponse = Stage("download_response")
+	// StageFFmpeg includes downloading the body
+	StageFFmpeg = Stage("ffmpeg")
+	StageASR    = Stage("asr")
+)
+
+var (
+	VoiceMessagesSeen = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "voice_messages_seen_total",
+		Help:      "Voice messages seen in supported servers.",
+	})
+	VoiceMessagesSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "voice_messages_skipped_total",
+		Help:      "Voice messages that weren't transcribed, by reason.",
+	}, []string{"reason"})
+
+	TranscriptionsStarted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transcriptions_started_total",
+		Help:      "Transcriptions started.",
+	})
+	TranscriptionsDone = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transcriptions_done_total",
+		Help:      "Transcriptions finished successfully.",
+	})
+	TranscriptionsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transcriptions_failed_total",
+		Help:      "Transcriptions that failed.",
+	})
+	TranscriptionsPruned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transcriptions_pruned_total",
+		Help:      "Transcriptions deleted by the retention job.",
+	})
+
+	StageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transcription_stage_duration_seconds",
+		Help:      "Time spent in each stage of the transcription pipeline.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"stage"})
+
+	MediaProcessWaitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "media_process_wait_seconds",
+		Help:      "Time spent waiting for a free slot to start ffmpeg or ffprobe.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+	})
+	MediaProcessesRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "media_processes_running",
+		Help:      "ffmpeg and ffprobe processes holding a concurrency slot.",
+	})
+	MediaProcessesWaiting = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "media_processes_waiting",
+		Help:      "ffmpeg and ffprobe processes waiting for a concurrency slot.",
+	})
+
+	DiscordAPIErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discord_api_errors_total",
+		Help:      "Failed Discord API requests, by HTTP status or \"transport\" if the request didn't complete.",
+	}, []string{"status"})
+
+	TemplateRenderFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "template_render_failures_total",
+		Help:      "Message templates that failed to render, by message name.",
+	}, []string{"message"})
+)
+
+func SkipVoiceMessage(reason SkipReason) {
+	VoiceMessagesSkipped.WithLabelValues(string(reason)).Inc()
+}
+
+// ObserveStage records how long a transcription stage took, in seconds.
+func ObserveStage(stage Stage, seconds float64) {
+	StageDuration.WithLabelValues(string(stage)).Observe(seconds)
+}
+
+type discordTransport struct {
+	next http.RoundTripper
+}
+
+func (t discordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		DiscordAPIErrors.WithLabelValues("transport").Inc()
+		return resp, err
+	}
+	if resp.StatusCode >= 400 {
+		DiscordAPIErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, nil
+}
+
+// DiscordTransport wraps next, counting failed requests in DiscordAPIErrors.
+// A nil next uses http.DefaultTransport.
+func DiscordTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return discordTransport{next: next}
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// buckets that are full and untouched for this long are dropped
+const pruneInterval = time.Hour
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is an in-memory token bucket rate limiter, with one bucket per key.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	// tokens added per second
+	rate  float64
+	burst float64
+
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a limiter that allows perHour events per key per hour,
+// with up to burst events at once. If perHour is 0, everything is allowed.
+func NewLimiter(perHour float64, burst int) *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		rate:      perHour / time.Hour.Seconds(),
+		burst:     math.Max(float64(burst), 1),
+		lastPrune: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket. If there are none left, it returns
+// false and how long until the next token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:  l.burst,
+			updated: now,
+		}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// Return gives back a token taken by Allow, for an event that ended up not
+// happening.
+func (l *Limiter) Return(key string) {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(l.burst, b.tokens+1)
+	}
+}
+
+// prune drops buckets that would have refilled by now, since they're
+// equivalent to new ones. l.mu must be held.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
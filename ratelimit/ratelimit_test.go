@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter whose clock only moves when the returned
+// function is called.
+func newTestLimiter(perHour float64, burst int) (*Limiter, func(time.Duration)) {
+	now := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLimiter(perHour, burst)
+	l.now = func() time.Time { return now }
+	l.lastPrune = now
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l, _ := newTestLimiter(60, 3)
+
+	for i := range 3 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("event %d was limited within the burst", i)
+		}
+	}
+
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatalf("event after the burst was allowed")
+	}
+	if wait != time.Minute {
+		t.Errorf("wait is %s, want 1m", wait)
+	}
+
+	// buckets are per key
+	if ok, _ := l.Allow("b"); !ok {
+		t.Errorf("another key was limited")
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l, advance := newTestLimiter(60, 2)
+
+	l.Allow("a")
+	l.Allow("a")
+
+	advance(30 * time.Second)
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatalf("allowed before a token refilled")
+	}
+	if wait != 30*time.Second {
+		t.Errorf("wait is %s, want 30s", wait)
+	}
+
+	advance(30 * time.Second)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatalf("limited after a token refilled")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatalf("allowed a second event with one token refilled")
+	}
+
+	// refilling stops at the burst
+	advance(time.Hour)
+	for i := range 2 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("event %d was limited after a full refill", i)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatalf("refilled past the burst")
+	}
+}
+
+func TestLimiterReturn(t *testing.T) {
+	l, _ := newTestLimiter(60, 1)
+
+	l.Allow("a")
+	l.Return("a")
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatalf("limited after the token was returned")
+	}
+
+	// returning can't go past the burst
+	l.Return("a")
+	l.Return("a")
+	l.Allow("a")
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatalf("returned past the burst")
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	// refills in 2 hours, so a bucket is only full at the second prune
+	l, advance := newTestLimiter(1, 2)
+
+	l.Allow("a")
+	l.Allow("a")
+	l.Allow("full")
+
+	advance(pruneInterval)
+	l.Allow("b")
+	if _, ok := l.buckets["a"]; !ok {
+		t.Errorf("pruned a bucket that hasn't refilled")
+	}
+	if _, ok := l.buckets["full"]; ok {
+		t.Errorf("kept a bucket that has refilled")
+	}
+
+	// no prune until the interval passes again
+	advance(pruneInterval - time.Second)
+	l.Allow("c")
+	if _, ok := l.buckets["a"]; !ok {
+		t.Errorf("pruned before the interval passed")
+	}
+
+	advance(time.Second)
+	l.Allow("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Errorf("kept a bucket that has refilled")
+	}
+	if len(l.buckets) != 1 {
+		t.Errorf("buckets after pruning are %v, want only the one just used", l.buckets)
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l, _ := newTestLimiter(0, 1)
+	for i := range 10 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("event %d was limited with no rate", i)
+		}
+	}
+}
@@ -11,6 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type LowConfidenceAction string
+
+const (
+	LowConfidenceActionFlag     LowConfidenceAction = "flag"
+	LowConfidenceActionSuppress LowConfidenceAction = "suppress"
+)
+
+func (e *LowConfidenceAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LowConfidenceAction(s)
+	case string:
+		*e = LowConfidenceAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LowConfidenceAction: %T", src)
+	}
+	return nil
+}
+
+type NullLowConfidenceAction struct {
+	LowConfidenceAction LowConfidenceAction
+	Valid               bool // Valid is true if LowConfidenceAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLowConfidenceAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.LowConfidenceAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LowConfidenceAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLowConfidenceAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LowConfidenceAction), nil
+}
+
 type TranscriptionStatus string
 
 const (
@@ -54,6 +96,15 @@ func (ns NullTranscriptionStatus) Value() (driver.Value, error) {
 	return string(ns.TranscriptionStatus), nil
 }
 
+type AsrTranscriptText struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	UserID            string
+	CreatedAt         pgtype.Timestamptz
+	TextEncrypted     []byte
+}
+
 type AsrTranscription struct {
 	GuildID                     string
 	ChannelID                   string
@@ -66,12 +117,34 @@ type AsrTranscription struct {
 	VoiceMessageAudioDuration   pgtype.Float8
 	TranscriptionModel          pgtype.Text
 	TranscriptionProcessingTime pgtype.Float8
+	AuthorID                    pgtype.Text
+	RequesterID                 pgtype.Text
+}
+
+type AsrTranscriptionDailyStat struct {
+	Day                pgtype.Date
+	GuildID            string
+	TranscriptionModel string
+	Transcriptions     int64
+	Failed             int64
+	AudioDuration      float64
+}
+
+type GuildSetting struct {
+	GuildID                string
+	UserDailyAudioSeconds  pgtype.Float8
+	GuildDailyAudioSeconds pgtype.Float8
+	UpdatedAt              pgtype.Timestamptz
+	UpdatedBy              pgtype.Text
+	LowConfidenceAction    LowConfidenceAction
 }
 
 type User struct {
-	ID                  string
-	AsrEnabled          bool
-	AsrEnabledTouchedAt pgtype.Timestamptz
-	AsrNudged           bool
-	AsrNudgedTouchedAt  pgtype.Timestamptz
+	ID                      string
+	AsrEnabled              bool
+	AsrEnabledTouchedAt     pgtype.Timestamptz
+	AsrNudged               bool
+	AsrNudgedTouchedAt      pgtype.Timestamptz
+	HistoryEnabled          bool
+	HistoryEnabledTouchedAt pgtype.Timestamptz
 }
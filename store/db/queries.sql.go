@@ -11,6 +11,84 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addTranscriptionDailyStats = `-- name: AddTranscriptionDailyStats :exec
+INSERT INTO asr_transcription_daily_stats (
+    day,
+    guild_id,
+    transcription_model,
+    transcriptions,
+    failed,
+    audio_duration
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (day, guild_id, transcription_model) DO UPDATE
+SET
+    transcriptions=asr_transcription_daily_stats.transcriptions+EXCLUDED.transcriptions,
+    failed=asr_transcription_daily_stats.failed+EXCLUDED.failed,
+    audio_duration=asr_transcription_daily_stats.audio_duration+EXCLUDED.audio_duration
+`
+
+type AddTranscriptionDailyStatsParams struct {
+	Day                pgtype.Date
+	GuildID            string
+	TranscriptionModel string
+	Transcriptions     int64
+	Failed             int64
+	AudioDuration      float64
+}
+
+func (q *Queries) AddTranscriptionDailyStats(ctx context.Context, arg AddTranscriptionDailyStatsParams) error {
+	_, err := q.db.Exec(ctx, addTranscriptionDailyStats,
+		arg.Day,
+		arg.GuildID,
+		arg.TranscriptionModel,
+		arg.Transcriptions,
+		arg.Failed,
+		arg.AudioDuration,
+	)
+	return err
+}
+
+const clearTranscriptionsRequester = `-- name: ClearTranscriptionsRequester :many
+UPDATE asr_transcriptions
+SET requester_id=NULL
+WHERE requester_id=$1::text
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+func (q *Queries) ClearTranscriptionsRequester(ctx context.Context, requesterID string) ([]AsrTranscription, error) {
+	rows, err := q.db.Query(ctx, clearTranscriptionsRequester, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createStartedTranscription = `-- name: CreateStartedTranscription :exec
 INSERT INTO asr_transcriptions (
     guild_id,
@@ -19,8 +97,10 @@ INSERT INTO asr_transcriptions (
     original_message_deleted,
     original_message_timestamp,
     response_message_id,
+    author_id,
+    requester_id,
     transcription_status
-) VALUES ($1, $2, $3, $4, $5, $6, 'started')
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'started')
 `
 
 type CreateStartedTranscriptionParams struct {
@@ -30,6 +110,8 @@ type CreateStartedTranscriptionParams struct {
 	OriginalMessageDeleted   bool
 	OriginalMessageTimestamp pgtype.Timestamptz
 	ResponseMessageID        string
+	AuthorID                 pgtype.Text
+	RequesterID              pgtype.Text
 }
 
 func (q *Queries) CreateStartedTranscription(ctx context.Context, arg CreateStartedTranscriptionParams) error {
@@ -40,6 +122,42 @@ func (q *Queries) CreateStartedTranscription(ctx context.Context, arg CreateStar
 		arg.OriginalMessageDeleted,
 		arg.OriginalMessageTimestamp,
 		arg.ResponseMessageID,
+		arg.AuthorID,
+		arg.RequesterID,
+	)
+	return err
+}
+
+const createTranscriptText = `-- name: CreateTranscriptText :exec
+INSERT INTO asr_transcript_texts (
+    guild_id,
+    channel_id,
+    original_message_id,
+    user_id,
+    text_encrypted
+) VALUES (
+    $1, $2, $3, $4,
+    pgp_sym_encrypt($5::text, $6::text)
+)
+`
+
+type CreateTranscriptTextParams struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	UserID            string
+	Text              string
+	Key               string
+}
+
+func (q *Queries) CreateTranscriptText(ctx context.Context, arg CreateTranscriptTextParams) error {
+	_, err := q.db.Exec(ctx, createTranscriptText,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.OriginalMessageID,
+		arg.UserID,
+		arg.Text,
+		arg.Key,
 	)
 	return err
 }
@@ -55,8 +173,151 @@ func (q *Queries) CreateUser(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteTranscriptTextsByUser = `-- name: DeleteTranscriptTextsByUser :execrows
+DELETE FROM asr_transcript_texts
+WHERE user_id=$1
+`
+
+func (q *Queries) DeleteTranscriptTextsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTranscriptTextsByUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTranscriptionsByAuthor = `-- name: DeleteTranscriptionsByAuthor :many
+DELETE FROM asr_transcriptions
+WHERE author_id=$1::text
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+func (q *Queries) DeleteTranscriptionsByAuthor(ctx context.Context, authorID string) ([]AsrTranscription, error) {
+	rows, err := q.db.Query(ctx, deleteTranscriptionsByAuthor, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id=$1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getGuildSettings = `-- name: GetGuildSettings :one
+SELECT guild_id, user_daily_audio_seconds, guild_daily_audio_seconds, updated_at, updated_by, low_confidence_action FROM guild_settings
+WHERE guild_id=$1 LIMIT 1
+`
+
+func (q *Queries) GetGuildSettings(ctx context.Context, guildID string) (GuildSetting, error) {
+	row := q.db.QueryRow(ctx, getGuildSettings, guildID)
+	var i GuildSetting
+	err := row.Scan(
+		&i.GuildID,
+		&i.UserDailyAudioSeconds,
+		&i.GuildDailyAudioSeconds,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.LowConfidenceAction,
+	)
+	return i, err
+}
+
+const getGuildTranscriptionTotals = `-- name: GetGuildTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='done') AS transcriptions_done,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration
+FROM asr_transcriptions
+WHERE
+    guild_id=$1::text AND
+    original_message_timestamp >= $2::timestamptz
+`
+
+type GetGuildTranscriptionTotalsParams struct {
+	GuildID string
+	Since   pgtype.Timestamptz
+}
+
+type GetGuildTranscriptionTotalsRow struct {
+	Transcriptions     int64
+	TranscriptionsDone int64
+	AudioDuration      float64
+}
+
+func (q *Queries) GetGuildTranscriptionTotals(ctx context.Context, arg GetGuildTranscriptionTotalsParams) (GetGuildTranscriptionTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getGuildTranscriptionTotals, arg.GuildID, arg.Since)
+	var i GetGuildTranscriptionTotalsRow
+	err := row.Scan(&i.Transcriptions, &i.TranscriptionsDone, &i.AudioDuration)
+	return i, err
+}
+
+const getRequesterTranscriptionTotals = `-- name: GetRequesterTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='done') AS transcriptions_done,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration
+FROM asr_transcriptions
+WHERE
+    requester_id=$1::text AND
+    original_message_timestamp >= $2::timestamptz
+`
+
+type GetRequesterTranscriptionTotalsParams struct {
+	RequesterID string
+	Since       pgtype.Timestamptz
+}
+
+type GetRequesterTranscriptionTotalsRow struct {
+	Transcriptions     int64
+	TranscriptionsDone int64
+	AudioDuration      float64
+}
+
+func (q *Queries) GetRequesterTranscriptionTotals(ctx context.Context, arg GetRequesterTranscriptionTotalsParams) (GetRequesterTranscriptionTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getRequesterTranscriptionTotals, arg.RequesterID, arg.Since)
+	var i GetRequesterTranscriptionTotalsRow
+	err := row.Scan(&i.Transcriptions, &i.TranscriptionsDone, &i.AudioDuration)
+	return i, err
+}
+
 const getTranscriptionByOriginalMessage = `-- name: GetTranscriptionByOriginalMessage :one
-SELECT guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time FROM asr_transcriptions
+SELECT guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id FROM asr_transcriptions
 WHERE 
     guild_id=$1 AND
     channel_id=$2 AND
@@ -85,12 +346,224 @@ func (q *Queries) GetTranscriptionByOriginalMessage(ctx context.Context, arg Get
 		&i.VoiceMessageAudioDuration,
 		&i.TranscriptionModel,
 		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
+	)
+	return i, err
+}
+
+const getTranscriptionStatsByDay = `-- name: GetTranscriptionStatsByDay :many
+SELECT
+    date_trunc('day', original_message_timestamp, 'UTC')::timestamptz AS day,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    ($1::text IS NULL OR guild_id=$1::text) AND
+    original_message_timestamp >= $2::timestamptz
+GROUP BY day
+ORDER BY day DESC
+`
+
+type GetTranscriptionStatsByDayParams struct {
+	GuildID pgtype.Text
+	Since   pgtype.Timestamptz
+}
+
+type GetTranscriptionStatsByDayRow struct {
+	Day               pgtype.Timestamptz
+	Transcriptions    int64
+	Failed            int64
+	AudioDuration     float64
+	ProcessingTimeP50 float64
+	ProcessingTimeP95 float64
+}
+
+func (q *Queries) GetTranscriptionStatsByDay(ctx context.Context, arg GetTranscriptionStatsByDayParams) ([]GetTranscriptionStatsByDayRow, error) {
+	rows, err := q.db.Query(ctx, getTranscriptionStatsByDay, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTranscriptionStatsByDayRow
+	for rows.Next() {
+		var i GetTranscriptionStatsByDayRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Transcriptions,
+			&i.Failed,
+			&i.AudioDuration,
+			&i.ProcessingTimeP50,
+			&i.ProcessingTimeP95,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTranscriptionStatsByGuild = `-- name: GetTranscriptionStatsByGuild :many
+SELECT
+    guild_id,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    ($1::text IS NULL OR guild_id=$1::text) AND
+    original_message_timestamp >= $2::timestamptz
+GROUP BY guild_id
+ORDER BY transcriptions DESC
+`
+
+type GetTranscriptionStatsByGuildParams struct {
+	GuildID pgtype.Text
+	Since   pgtype.Timestamptz
+}
+
+type GetTranscriptionStatsByGuildRow struct {
+	GuildID           string
+	Transcriptions    int64
+	Failed            int64
+	AudioDuration     float64
+	ProcessingTimeP50 float64
+	ProcessingTimeP95 float64
+}
+
+func (q *Queries) GetTranscriptionStatsByGuild(ctx context.Context, arg GetTranscriptionStatsByGuildParams) ([]GetTranscriptionStatsByGuildRow, error) {
+	rows, err := q.db.Query(ctx, getTranscriptionStatsByGuild, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTranscriptionStatsByGuildRow
+	for rows.Next() {
+		var i GetTranscriptionStatsByGuildRow
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.Transcriptions,
+			&i.Failed,
+			&i.AudioDuration,
+			&i.ProcessingTimeP50,
+			&i.ProcessingTimeP95,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTranscriptionStatsByModel = `-- name: GetTranscriptionStatsByModel :many
+SELECT
+    COALESCE(transcription_model, '')::text AS transcription_model,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    ($1::text IS NULL OR guild_id=$1::text) AND
+    original_message_timestamp >= $2::timestamptz
+GROUP BY transcription_model
+ORDER BY transcriptions DESC
+`
+
+type GetTranscriptionStatsByModelParams struct {
+	GuildID pgtype.Text
+	Since   pgtype.Timestamptz
+}
+
+type GetTranscriptionStatsByModelRow struct {
+	TranscriptionModel string
+	Transcriptions     int64
+	Failed             int64
+	AudioDuration      float64
+	ProcessingTimeP50  float64
+	ProcessingTimeP95  float64
+}
+
+func (q *Queries) GetTranscriptionStatsByModel(ctx context.Context, arg GetTranscriptionStatsByModelParams) ([]GetTranscriptionStatsByModelRow, error) {
+	rows, err := q.db.Query(ctx, getTranscriptionStatsByModel, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTranscriptionStatsByModelRow
+	for rows.Next() {
+		var i GetTranscriptionStatsByModelRow
+		if err := rows.Scan(
+			&i.TranscriptionModel,
+			&i.Transcriptions,
+			&i.Failed,
+			&i.AudioDuration,
+			&i.ProcessingTimeP50,
+			&i.ProcessingTimeP95,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTranscriptionStatsTotal = `-- name: GetTranscriptionStatsTotal :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    ($1::text IS NULL OR guild_id=$1::text) AND
+    original_message_timestamp >= $2::timestamptz
+`
+
+type GetTranscriptionStatsTotalParams struct {
+	GuildID pgtype.Text
+	Since   pgtype.Timestamptz
+}
+
+type GetTranscriptionStatsTotalRow struct {
+	Transcriptions    int64
+	Failed            int64
+	AudioDuration     float64
+	ProcessingTimeP50 float64
+	ProcessingTimeP95 float64
+}
+
+func (q *Queries) GetTranscriptionStatsTotal(ctx context.Context, arg GetTranscriptionStatsTotalParams) (GetTranscriptionStatsTotalRow, error) {
+	row := q.db.QueryRow(ctx, getTranscriptionStatsTotal, arg.GuildID, arg.Since)
+	var i GetTranscriptionStatsTotalRow
+	err := row.Scan(
+		&i.Transcriptions,
+		&i.Failed,
+		&i.AudioDuration,
+		&i.ProcessingTimeP50,
+		&i.ProcessingTimeP95,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :one
-SELECT id, asr_enabled, asr_enabled_touched_at, asr_nudged, asr_nudged_touched_at FROM users
+SELECT id, asr_enabled, asr_enabled_touched_at, asr_nudged, asr_nudged_touched_at, history_enabled, history_enabled_touched_at FROM users
 WHERE id=$1 LIMIT 1
 `
 
@@ -103,10 +576,334 @@ func (q *Queries) GetUsers(ctx context.Context, id string) (User, error) {
 		&i.AsrEnabledTouchedAt,
 		&i.AsrNudged,
 		&i.AsrNudgedTouchedAt,
+		&i.HistoryEnabled,
+		&i.HistoryEnabledTouchedAt,
 	)
 	return i, err
 }
 
+const listAllTranscriptTexts = `-- name: ListAllTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    pgp_sym_decrypt(text_encrypted, $1::text)::text AS text
+FROM asr_transcript_texts
+WHERE user_id=$2
+ORDER BY created_at
+`
+
+type ListAllTranscriptTextsParams struct {
+	Key    string
+	UserID string
+}
+
+type ListAllTranscriptTextsRow struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	CreatedAt         pgtype.Timestamptz
+	Text              string
+}
+
+func (q *Queries) ListAllTranscriptTexts(ctx context.Context, arg ListAllTranscriptTextsParams) ([]ListAllTranscriptTextsRow, error) {
+	rows, err := q.db.Query(ctx, listAllTranscriptTexts, arg.Key, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllTranscriptTextsRow
+	for rows.Next() {
+		var i ListAllTranscriptTextsRow
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.CreatedAt,
+			&i.Text,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptTexts = `-- name: ListTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    pgp_sym_decrypt(text_encrypted, $1::text)::text AS text
+FROM asr_transcript_texts
+WHERE user_id=$2
+ORDER BY created_at DESC
+LIMIT $4 OFFSET $3
+`
+
+type ListTranscriptTextsParams struct {
+	Key       string
+	UserID    string
+	RowOffset int32
+	RowLimit  int32
+}
+
+type ListTranscriptTextsRow struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	CreatedAt         pgtype.Timestamptz
+	Text              string
+}
+
+func (q *Queries) ListTranscriptTexts(ctx context.Context, arg ListTranscriptTextsParams) ([]ListTranscriptTextsRow, error) {
+	rows, err := q.db.Query(ctx, listTranscriptTexts,
+		arg.Key,
+		arg.UserID,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTranscriptTextsRow
+	for rows.Next() {
+		var i ListTranscriptTextsRow
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.CreatedAt,
+			&i.Text,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptionDailyStats = `-- name: ListTranscriptionDailyStats :many
+SELECT day, guild_id, transcription_model, transcriptions, failed, audio_duration FROM asr_transcription_daily_stats
+WHERE
+    ($1::text IS NULL OR guild_id=$1::text) AND
+    day >= $2::date
+`
+
+type ListTranscriptionDailyStatsParams struct {
+	GuildID pgtype.Text
+	Since   pgtype.Date
+}
+
+func (q *Queries) ListTranscriptionDailyStats(ctx context.Context, arg ListTranscriptionDailyStatsParams) ([]AsrTranscriptionDailyStat, error) {
+	rows, err := q.db.Query(ctx, listTranscriptionDailyStats, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscriptionDailyStat
+	for rows.Next() {
+		var i AsrTranscriptionDailyStat
+		if err := rows.Scan(
+			&i.Day,
+			&i.GuildID,
+			&i.TranscriptionModel,
+			&i.Transcriptions,
+			&i.Failed,
+			&i.AudioDuration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptionsByAuthor = `-- name: ListTranscriptionsByAuthor :many
+SELECT guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id FROM asr_transcriptions
+WHERE author_id=$1::text
+ORDER BY original_message_timestamp
+`
+
+func (q *Queries) ListTranscriptionsByAuthor(ctx context.Context, authorID string) ([]AsrTranscription, error) {
+	rows, err := q.db.Query(ctx, listTranscriptionsByAuthor, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneTranscriptions = `-- name: PruneTranscriptions :many
+DELETE FROM asr_transcriptions
+WHERE (guild_id, channel_id, original_message_id) IN (
+    SELECT guild_id, channel_id, original_message_id
+    FROM asr_transcriptions
+    WHERE
+        original_message_timestamp < $1::timestamptz AND
+        -- keep transcriptions in someone's /history
+        NOT EXISTS (
+            SELECT 1 FROM asr_transcript_texts
+            WHERE
+                asr_transcript_texts.guild_id=asr_transcriptions.guild_id AND
+                asr_transcript_texts.channel_id=asr_transcriptions.channel_id AND
+                asr_transcript_texts.original_message_id=asr_transcriptions.original_message_id
+        )
+    ORDER BY original_message_timestamp
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+type PruneTranscriptionsParams struct {
+	Before   pgtype.Timestamptz
+	RowLimit int32
+}
+
+func (q *Queries) PruneTranscriptions(ctx context.Context, arg PruneTranscriptionsParams) ([]AsrTranscription, error) {
+	rows, err := q.db.Query(ctx, pruneTranscriptions, arg.Before, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTranscriptTexts = `-- name: SearchTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    text
+FROM (
+    SELECT
+        guild_id,
+        channel_id,
+        original_message_id,
+        created_at,
+        pgp_sym_decrypt(text_encrypted, $1::text)::text AS text
+    FROM asr_transcript_texts
+    WHERE user_id=$2
+) decrypted
+WHERE to_tsvector('simple', text) @@ websearch_to_tsquery('simple', $3::text)
+ORDER BY
+    ts_rank(to_tsvector('simple', text), websearch_to_tsquery('simple', $3::text)) DESC,
+    created_at DESC
+LIMIT $5 OFFSET $4
+`
+
+type SearchTranscriptTextsParams struct {
+	Key       string
+	UserID    string
+	Query     string
+	RowOffset int32
+	RowLimit  int32
+}
+
+type SearchTranscriptTextsRow struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	CreatedAt         pgtype.Timestamptz
+	Text              string
+}
+
+func (q *Queries) SearchTranscriptTexts(ctx context.Context, arg SearchTranscriptTextsParams) ([]SearchTranscriptTextsRow, error) {
+	rows, err := q.db.Query(ctx, searchTranscriptTexts,
+		arg.Key,
+		arg.UserID,
+		arg.Query,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTranscriptTextsRow
+	for rows.Next() {
+		var i SearchTranscriptTextsRow
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.CreatedAt,
+			&i.Text,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTranscriptionDone = `-- name: UpdateTranscriptionDone :one
 UPDATE asr_transcriptions
 SET 
@@ -118,7 +915,7 @@ WHERE
     guild_id=$1 AND
     channel_id=$2 AND
     original_message_id=$3
-RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
 `
 
 type UpdateTranscriptionDoneParams struct {
@@ -152,6 +949,8 @@ func (q *Queries) UpdateTranscriptionDone(ctx context.Context, arg UpdateTranscr
 		&i.VoiceMessageAudioDuration,
 		&i.TranscriptionModel,
 		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
 	)
 	return i, err
 }
@@ -164,7 +963,7 @@ WHERE
     guild_id=$1 AND
     channel_id=$2 AND
     original_message_id=$3
-RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
 `
 
 type UpdateTranscriptionFailedParams struct {
@@ -188,6 +987,8 @@ func (q *Queries) UpdateTranscriptionFailed(ctx context.Context, arg UpdateTrans
 		&i.VoiceMessageAudioDuration,
 		&i.TranscriptionModel,
 		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
 	)
 	return i, err
 }
@@ -207,7 +1008,7 @@ WHERE
         original_message_id=$3::text OR 
         response_message_id=$3::text
     )
-RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
 `
 
 type UpdateTranscriptionMessageDeletedParams struct {
@@ -231,6 +1032,8 @@ func (q *Queries) UpdateTranscriptionMessageDeleted(ctx context.Context, arg Upd
 		&i.VoiceMessageAudioDuration,
 		&i.TranscriptionModel,
 		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
 	)
 	return i, err
 }
@@ -266,3 +1069,65 @@ func (q *Queries) UpdateUserASRNudge(ctx context.Context, arg UpdateUserASRNudge
 	_, err := q.db.Exec(ctx, updateUserASRNudge, arg.AsrNudged, arg.ID)
 	return err
 }
+
+const updateUserHistoryEnabled = `-- name: UpdateUserHistoryEnabled :exec
+UPDATE users
+SET history_enabled=$1, history_enabled_touched_at=NOW()
+WHERE id=$2
+`
+
+type UpdateUserHistoryEnabledParams struct {
+	HistoryEnabled bool
+	ID             string
+}
+
+func (q *Queries) UpdateUserHistoryEnabled(ctx context.Context, arg UpdateUserHistoryEnabledParams) error {
+	_, err := q.db.Exec(ctx, updateUserHistoryEnabled, arg.HistoryEnabled, arg.ID)
+	return err
+}
+
+const upsertGuildSettings = `-- name: UpsertGuildSettings :one
+INSERT INTO guild_settings (
+    guild_id,
+    user_daily_audio_seconds,
+    guild_daily_audio_seconds,
+    low_confidence_action,
+    updated_by
+) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (guild_id) DO UPDATE
+SET
+    user_daily_audio_seconds=EXCLUDED.user_daily_audio_seconds,
+    guild_daily_audio_seconds=EXCLUDED.guild_daily_audio_seconds,
+    low_confidence_action=EXCLUDED.low_confidence_action,
+    updated_by=EXCLUDED.updated_by,
+    updated_at=NOW()
+RETURNING guild_id, user_daily_audio_seconds, guild_daily_audio_seconds, updated_at, updated_by, low_confidence_action
+`
+
+type UpsertGuildSettingsParams struct {
+	GuildID                string
+	UserDailyAudioSeconds  pgtype.Float8
+	GuildDailyAudioSeconds pgtype.Float8
+	LowConfidenceAction    LowConfidenceAction
+	UpdatedBy              pgtype.Text
+}
+
+func (q *Queries) UpsertGuildSettings(ctx context.Context, arg UpsertGuildSettingsParams) (GuildSetting, error) {
+	row := q.db.QueryRow(ctx, upsertGuildSettings,
+		arg.GuildID,
+		arg.UserDailyAudioSeconds,
+		arg.GuildDailyAudioSeconds,
+		arg.LowConfidenceAction,
+		arg.UpdatedBy,
+	)
+	var i GuildSetting
+	err := row.Scan(
+		&i.GuildID,
+		&i.UserDailyAudioSeconds,
+		&i.GuildDailyAudioSeconds,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.LowConfidenceAction,
+	)
+	return i, err
+}
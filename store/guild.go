@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetGuildSettingsOrDefault gets the guild's settings, returning empty settings
+// (deployment defaults) if the guild never changed them.
+func (s *postgresStore) GetGuildSettingsOrDefault(ctx context.Context, guildID string) (*db.GuildSetting, error) {
+	settings, err := s.GetGuildSettings(ctx, guildID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &db.GuildSetting{
+			GuildID:             guildID,
+			LowConfidenceAction: db.LowConfidenceActionFlag,
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting guild settings: %w", err)
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/K3das/orange/store/db"
+)
+
+var ErrHistoryUnavailable = fmt.Errorf("transcript history is not configured")
+
+type TranscriptHistoryEntry struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	CreatedAt         time.Time
+	Text              string
+}
+
+// HistoryAvailable reports whether a transcript encryption key was configured.
+func (s *postgresStore) HistoryAvailable() bool {
+	return s.transcriptKey != ""
+}
+
+// StoreTranscriptText encrypts and saves the text of a finished transcription.
+func (s *postgresStore) StoreTranscriptText(ctx context.Context, transcription *db.AsrTranscription, userID string, text string) error {
+	if !s.HistoryAvailable() {
+		return ErrHistoryUnavailable
+	}
+
+	return s.CreateTranscriptText(ctx, db.CreateTranscriptTextParams{
+		GuildID:           transcription.GuildID,
+		ChannelID:         transcription.ChannelID,
+		OriginalMessageID: transcription.OriginalMessageID,
+		UserID:            userID,
+		Text:              text,
+		Key:               s.transcriptKey,
+	})
+}
+
+// GetTranscriptHistory returns a page of the user's stored transcripts, newest
+// first. If query has words, it's used as a full-text search and results are
+// ordered by relevance instead.
+func (s *postgresStore) GetTranscriptHistory(ctx context.Context, userID string, query string, limit, offset int32) ([]TranscriptHistoryEntry, error) {
+	if !s.HistoryAvailable() {
+		return nil, ErrHistoryUnavailable
+	}
+
+	var entries []TranscriptHistoryEntry
+	if !searchQuery(query) {
+		rows, err := s.ListTranscriptTexts(ctx, db.ListTranscriptTextsParams{
+			Key:       s.transcriptKey,
+			UserID:    userID,
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing transcripts: %w", err)
+		}
+		for _, row := range rows {
+			entries = append(entries, TranscriptHistoryEntry{
+				GuildID:           row.GuildID,
+				ChannelID:         row.ChannelID,
+				OriginalMessageID: row.OriginalMessageID,
+				CreatedAt:         row.CreatedAt.Time,
+				Text:              row.Text,
+			})
+		}
+	} else {
+		rows, err := s.SearchTranscriptTexts(ctx, db.SearchTranscriptTextsParams{
+			Key:       s.transcriptKey,
+			UserID:    userID,
+			Query:     query,
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("searching transcripts: %w", err)
+		}
+		for _, row := range rows {
+			entries = append(entries, TranscriptHistoryEntry{
+				GuildID:           row.GuildID,
+				ChannelID:         row.ChannelID,
+				OriginalMessageID: row.OriginalMessageID,
+				CreatedAt:         row.CreatedAt.Time,
+				Text:              row.Text,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// searchTokens splits text into lowercase words, roughly like Postgres'
+// 'simple' text search configuration.
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchQuery reports whether query has words to search for. Queries without
+// any, like "?!", list history like an empty one, since websearch_to_tsquery
+// can't match anything with them.
+func searchQuery(query string) bool {
+	return len(searchTokens(query)) > 0
+}
+
+// searchRank is how often the query's words appear in the text, or 0 if any
+// is missing. It approximates websearch_to_tsquery and ts_rank for plain
+// word queries.
+func searchRank(text string, query []string) int {
+	counts := map[string]int{}
+	for _, token := range searchTokens(text) {
+		counts[token]++
+	}
+
+	rank := 0
+	for _, word := range query {
+		if counts[word] == 0 {
+			return 0
+		}
+		rank += counts[word]
+	}
+	return rank
+}
+
+// pageHistory orders entries newest first, or by relevance if query has
+// words, and returns a page of them. It's for backends without full-text
+// search.
+func pageHistory(entries []TranscriptHistoryEntry, query string, limit, offset int32) []TranscriptHistoryEntry {
+	type rankedEntry struct {
+		TranscriptHistoryEntry
+		rank int
+	}
+
+	queryTokens := searchTokens(query)
+
+	var ranked []rankedEntry
+	for _, entry := range entries {
+		rank := 0
+		if len(queryTokens) > 0 {
+			rank = searchRank(entry.Text, queryTokens)
+			if rank == 0 {
+				continue
+			}
+		}
+		ranked = append(ranked, rankedEntry{entry, rank})
+	}
+
+	slices.SortFunc(ranked, func(a, b rankedEntry) int {
+		if a.rank != b.rank {
+			return b.rank - a.rank
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	var page []TranscriptHistoryEntry
+	for i := int(offset); i < len(ranked) && i < int(offset)+int(limit); i++ {
+		page = append(page, ranked[i].TranscriptHistoryEntry)
+	}
+	return page
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-func (s *Store) GetOrCreateUser(ctx context.Context, memberID string) (*db.User, error) {
+func (s *postgresStore) GetOrCreateUser(ctx context.Context, memberID string) (*db.User, error) {
 	err := s.CreateUser(ctx, memberID)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("creating member: %w", err)
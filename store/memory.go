@@ -0,0 +1,500 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MemoryStore is a Repository kept in memory, with the same semantics as the
+// Postgres store. It's for tests and local development, nothing is persisted.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	history bool
+
+	users          map[string]db.User
+	transcriptions map[memoryMessageKey]db.AsrTranscription
+	transcripts    map[memoryMessageKey]memoryTranscript
+	guildSettings  map[string]db.GuildSetting
+	dailyStats     []dailyStats
+}
+
+type memoryMessageKey struct {
+	guildID           string
+	channelID         string
+	originalMessageID string
+}
+
+type memoryTranscript struct {
+	userID    string
+	createdAt time.Time
+	text      string
+}
+
+type MemoryStoreOptions func(*MemoryStore)
+
+// WithMemoryHistory enables transcript history, like configuring a transcript
+// key for the Postgres store.
+func WithMemoryHistory() MemoryStoreOptions {
+	return func(s *MemoryStore) {
+		s.history = true
+	}
+}
+
+func NewMemoryStore(options ...MemoryStoreOptions) *MemoryStore {
+	s := &MemoryStore{
+		users:          map[string]db.User{},
+		transcriptions: map[memoryMessageKey]db.AsrTranscription{},
+		transcripts:    map[memoryMessageKey]memoryTranscript{},
+		guildSettings:  map[string]db.GuildSetting{},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+func memoryNow() pgtype.Timestamptz {
+	return pgtype.Timestamptz{
+		Time:  time.Now(),
+		Valid: true,
+	}
+}
+
+func (s *MemoryStore) GetOrCreateUser(ctx context.Context, userID string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		user = db.User{ID: userID}
+		s.users[userID] = user
+	}
+
+	return &user, nil
+}
+
+// updateUser changes a user if they exist, updating a missing user is a no-op
+// like in SQL.
+func (s *MemoryStore) updateUser(userID string, update func(user *db.User)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return
+	}
+	update(&user)
+	s.users[userID] = user
+}
+
+func (s *MemoryStore) UpdateUserASREnabled(ctx context.Context, arg db.UpdateUserASREnabledParams) error {
+	s.updateUser(arg.ID, func(user *db.User) {
+		user.AsrEnabled = arg.AsrEnabled
+		user.AsrEnabledTouchedAt = memoryNow()
+	})
+	return nil
+}
+
+func (s *MemoryStore) UpdateUserASRNudge(ctx context.Context, arg db.UpdateUserASRNudgeParams) error {
+	s.updateUser(arg.ID, func(user *db.User) {
+		user.AsrNudged = arg.AsrNudged
+		user.AsrNudgedTouchedAt = memoryNow()
+	})
+	return nil
+}
+
+func (s *MemoryStore) UpdateUserHistoryEnabled(ctx context.Context, arg db.UpdateUserHistoryEnabledParams) error {
+	s.updateUser(arg.ID, func(user *db.User) {
+		user.HistoryEnabled = arg.HistoryEnabled
+		user.HistoryEnabledTouchedAt = memoryNow()
+	})
+	return nil
+}
+
+func (s *MemoryStore) CreateStartedTranscription(ctx context.Context, arg db.CreateStartedTranscriptionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryMessageKey{arg.GuildID, arg.ChannelID, arg.OriginalMessageID}
+	if _, ok := s.transcriptions[key]; ok {
+		return fmt.Errorf("transcription of %s already exists", arg.OriginalMessageID)
+	}
+
+	s.transcriptions[key] = db.AsrTranscription{
+		GuildID:                  arg.GuildID,
+		ChannelID:                arg.ChannelID,
+		OriginalMessageID:        arg.OriginalMessageID,
+		OriginalMessageDeleted:   arg.OriginalMessageDeleted,
+		OriginalMessageTimestamp: arg.OriginalMessageTimestamp,
+		ResponseMessageID:        arg.ResponseMessageID,
+		TranscriptionStatus: db.NullTranscriptionStatus{
+			TranscriptionStatus: db.TranscriptionStatusStarted,
+			Valid:               true,
+		},
+		AuthorID:    arg.AuthorID,
+		RequesterID: arg.RequesterID,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) updateTranscription(key memoryMessageKey, update func(transcription *db.AsrTranscription)) (db.AsrTranscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transcription, ok := s.transcriptions[key]
+	if !ok {
+		return db.AsrTranscription{}, ErrNotFound
+	}
+	update(&transcription)
+	s.transcriptions[key] = transcription
+
+	return transcription, nil
+}
+
+func (s *MemoryStore) UpdateTranscriptionDone(ctx context.Context, arg db.UpdateTranscriptionDoneParams) (db.AsrTranscription, error) {
+	return s.updateTranscription(memoryMessageKey{arg.GuildID, arg.ChannelID, arg.OriginalMessageID}, func(transcription *db.AsrTranscription) {
+		transcription.TranscriptionStatus = db.NullTranscriptionStatus{
+			TranscriptionStatus: db.TranscriptionStatusDone,
+			Valid:               true,
+		}
+		transcription.VoiceMessageAudioDuration = arg.VoiceMessageAudioDuration
+		transcription.TranscriptionModel = arg.TranscriptionModel
+		transcription.TranscriptionProcessingTime = arg.TranscriptionProcessingTime
+	})
+}
+
+func (s *MemoryStore) UpdateTranscriptionFailed(ctx context.Context, arg db.UpdateTranscriptionFailedParams) (db.AsrTranscription, error) {
+	return s.updateTranscription(memoryMessageKey{arg.GuildID, arg.ChannelID, arg.OriginalMessageID}, func(transcription *db.AsrTranscription) {
+		transcription.TranscriptionStatus = db.NullTranscriptionStatus{
+			TranscriptionStatus: db.TranscriptionStatusFailed,
+			Valid:               true,
+		}
+	})
+}
+
+func (s *MemoryStore) UpdateTranscriptionMessageDeleted(ctx context.Context, arg db.UpdateTranscriptionMessageDeletedParams) (db.AsrTranscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, transcription := range s.transcriptions {
+		if transcription.GuildID != arg.GuildID || transcription.ChannelID != arg.ChannelID {
+			continue
+		}
+		if transcription.OriginalMessageID != arg.MessageID && transcription.ResponseMessageID != arg.MessageID {
+			continue
+		}
+
+		if transcription.ResponseMessageID == arg.MessageID {
+			transcription.ResponseDeleted = true
+		}
+		if transcription.OriginalMessageID == arg.MessageID {
+			transcription.OriginalMessageDeleted = true
+		}
+		s.transcriptions[key] = transcription
+
+		return transcription, nil
+	}
+
+	return db.AsrTranscription{}, ErrNotFound
+}
+
+// transcriptionTotals counts matching transcriptions since the given time, like
+// the Get*TranscriptionTotals queries.
+func (s *MemoryStore) transcriptionTotals(since pgtype.Timestamptz, match func(transcription db.AsrTranscription) bool) (transcriptions, done int64, audioDuration float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transcription := range s.transcriptions {
+		if transcription.OriginalMessageTimestamp.Time.Before(since.Time) || !match(transcription) {
+			continue
+		}
+
+		transcriptions++
+		if transcription.TranscriptionStatus.TranscriptionStatus == db.TranscriptionStatusDone {
+			done++
+		}
+		audioDuration += transcription.VoiceMessageAudioDuration.Float64
+	}
+
+	return transcriptions, done, audioDuration
+}
+
+func (s *MemoryStore) GetRequesterTranscriptionTotals(ctx context.Context, arg db.GetRequesterTranscriptionTotalsParams) (db.GetRequesterTranscriptionTotalsRow, error) {
+	transcriptions, done, audioDuration := s.transcriptionTotals(arg.Since, func(transcription db.AsrTranscription) bool {
+		return transcription.RequesterID.Valid && transcription.RequesterID.String == arg.RequesterID
+	})
+	return db.GetRequesterTranscriptionTotalsRow{
+		Transcriptions:     transcriptions,
+		TranscriptionsDone: done,
+		AudioDuration:      audioDuration,
+	}, nil
+}
+
+func (s *MemoryStore) GetGuildTranscriptionTotals(ctx context.Context, arg db.GetGuildTranscriptionTotalsParams) (db.GetGuildTranscriptionTotalsRow, error) {
+	transcriptions, done, audioDuration := s.transcriptionTotals(arg.Since, func(transcription db.AsrTranscription) bool {
+		return transcription.GuildID == arg.GuildID
+	})
+	return db.GetGuildTranscriptionTotalsRow{
+		Transcriptions:     transcriptions,
+		TranscriptionsDone: done,
+		AudioDuration:      audioDuration,
+	}, nil
+}
+
+func (s *MemoryStore) GetUsageStats(ctx context.Context, guildID string, since time.Time, pricesPerMinute map[string]float64) (*UsageStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transcriptions []db.AsrTranscription
+	for _, transcription := range s.transcriptions {
+		if guildID != "" && transcription.GuildID != guildID {
+			continue
+		}
+		if transcription.OriginalMessageTimestamp.Time.Before(since) {
+			continue
+		}
+		transcriptions = append(transcriptions, transcription)
+	}
+
+	sinceDay := rollupSince(since).Format(time.DateOnly)
+	var daily []dailyStats
+	for _, d := range s.dailyStats {
+		if guildID != "" && d.guildID != guildID {
+			continue
+		}
+		if d.day < sinceDay {
+			continue
+		}
+		daily = append(daily, d)
+	}
+
+	return aggregateUsageStats(transcriptions, daily, guildID, since, pricesPerMinute), nil
+}
+
+func (s *MemoryStore) GetGuildSettingsOrDefault(ctx context.Context, guildID string) (*db.GuildSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.guildSettings[guildID]
+	if !ok {
+		return &db.GuildSetting{
+			GuildID:             guildID,
+			LowConfidenceAction: db.LowConfidenceActionFlag,
+		}, nil
+	}
+
+	return &settings, nil
+}
+
+func (s *MemoryStore) UpsertGuildSettings(ctx context.Context, arg db.UpsertGuildSettingsParams) (db.GuildSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := db.GuildSetting{
+		GuildID:                arg.GuildID,
+		UserDailyAudioSeconds:  arg.UserDailyAudioSeconds,
+		GuildDailyAudioSeconds: arg.GuildDailyAudioSeconds,
+		UpdatedAt:              memoryNow(),
+		UpdatedBy:              arg.UpdatedBy,
+		LowConfidenceAction:    arg.LowConfidenceAction,
+	}
+	s.guildSettings[arg.GuildID] = settings
+
+	return settings, nil
+}
+
+func (s *MemoryStore) HistoryAvailable() bool {
+	return s.history
+}
+
+func (s *MemoryStore) StoreTranscriptText(ctx context.Context, transcription *db.AsrTranscription, userID string, text string) error {
+	if !s.HistoryAvailable() {
+		return ErrHistoryUnavailable
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryMessageKey{transcription.GuildID, transcription.ChannelID, transcription.OriginalMessageID}
+	if _, ok := s.transcriptions[key]; !ok {
+		return fmt.Errorf("transcription of %s doesn't exist", transcription.OriginalMessageID)
+	}
+	if _, ok := s.transcripts[key]; ok {
+		return fmt.Errorf("transcript of %s already exists", transcription.OriginalMessageID)
+	}
+
+	s.transcripts[key] = memoryTranscript{
+		userID:    userID,
+		createdAt: time.Now(),
+		text:      text,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetTranscriptHistory(ctx context.Context, userID string, query string, limit, offset int32) ([]TranscriptHistoryEntry, error) {
+	if !s.HistoryAvailable() {
+		return nil, ErrHistoryUnavailable
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []TranscriptHistoryEntry
+	for key, transcript := range s.transcripts {
+		if transcript.userID != userID {
+			continue
+		}
+		entries = append(entries, TranscriptHistoryEntry{
+			GuildID:           key.guildID,
+			ChannelID:         key.channelID,
+			OriginalMessageID: key.originalMessageID,
+			CreatedAt:         transcript.createdAt,
+			Text:              transcript.text,
+		})
+	}
+
+	return pageHistory(entries, query, limit, offset), nil
+}
+
+// transcriptionsByAuthor returns the user's authored transcriptions, oldest
+// first. The caller must hold the lock.
+func (s *MemoryStore) transcriptionsByAuthor(userID string) []db.AsrTranscription {
+	var transcriptions []db.AsrTranscription
+	for _, transcription := range s.transcriptions {
+		if transcription.AuthorID.Valid && transcription.AuthorID.String == userID {
+			transcriptions = append(transcriptions, transcription)
+		}
+	}
+	slices.SortFunc(transcriptions, func(a, b db.AsrTranscription) int {
+		return a.OriginalMessageTimestamp.Time.Compare(b.OriginalMessageTimestamp.Time)
+	})
+	return transcriptions
+}
+
+func (s *MemoryStore) ExportUserData(ctx context.Context, userID string) (*UserDataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	export := &UserDataExport{
+		ExportedAt:     time.Now().UTC(),
+		Transcriptions: []ExportedTranscription{},
+		Transcripts:    []ExportedTranscript{},
+	}
+
+	if user, ok := s.users[userID]; ok {
+		export.User = exportUser(user)
+	}
+
+	for _, transcription := range s.transcriptionsByAuthor(userID) {
+		export.Transcriptions = append(export.Transcriptions, exportTranscription(transcription))
+	}
+
+	if s.history {
+		for key, transcript := range s.transcripts {
+			if transcript.userID != userID {
+				continue
+			}
+			export.Transcripts = append(export.Transcripts, ExportedTranscript{
+				GuildID:           key.guildID,
+				ChannelID:         key.channelID,
+				OriginalMessageID: key.originalMessageID,
+				CreatedAt:         transcript.createdAt,
+				Text:              transcript.text,
+			})
+		}
+		slices.SortFunc(export.Transcripts, func(a, b ExportedTranscript) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+	}
+
+	return export, nil
+}
+
+func (s *MemoryStore) DeleteUserData(ctx context.Context, userID string) (*UserDataDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deletion := &UserDataDeletion{}
+
+	for key, transcript := range s.transcripts {
+		if transcript.userID == userID {
+			delete(s.transcripts, key)
+			deletion.TranscriptsDeleted++
+		}
+	}
+
+	deletion.Transcriptions = s.transcriptionsByAuthor(userID)
+	for _, transcription := range deletion.Transcriptions {
+		key := memoryMessageKey{transcription.GuildID, transcription.ChannelID, transcription.OriginalMessageID}
+		delete(s.transcriptions, key)
+		// ON DELETE CASCADE
+		delete(s.transcripts, key)
+	}
+
+	for key, transcription := range s.transcriptions {
+		if transcription.RequesterID.Valid && transcription.RequesterID.String == userID {
+			transcription.RequesterID = pgtype.Text{}
+			s.transcriptions[key] = transcription
+			deletion.RequestsCleared = append(deletion.RequestsCleared, transcription)
+		}
+	}
+
+	if _, ok := s.users[userID]; ok {
+		delete(s.users, userID)
+		deletion.UserDeleted = true
+	}
+
+	return deletion, nil
+}
+
+func (s *MemoryStore) PruneTranscriptions(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned []db.AsrTranscription
+	for key, transcription := range s.transcriptions {
+		if _, ok := s.transcripts[key]; ok {
+			continue
+		}
+		if transcription.OriginalMessageTimestamp.Time.Before(before) {
+			pruned = append(pruned, transcription)
+		}
+	}
+	slices.SortFunc(pruned, func(a, b db.AsrTranscription) int {
+		return a.OriginalMessageTimestamp.Time.Compare(b.OriginalMessageTimestamp.Time)
+	})
+	if len(pruned) > int(limit) {
+		pruned = pruned[:limit]
+	}
+
+	for _, transcription := range pruned {
+		key := memoryMessageKey{transcription.GuildID, transcription.ChannelID, transcription.OriginalMessageID}
+		delete(s.transcriptions, key)
+	}
+
+	for _, daily := range rollUpTranscriptions(pruned) {
+		i := slices.IndexFunc(s.dailyStats, func(d dailyStats) bool {
+			return d.day == daily.day && d.guildID == daily.guildID && d.model == daily.model
+		})
+		if i < 0 {
+			s.dailyStats = append(s.dailyStats, daily)
+			continue
+		}
+		s.dailyStats[i].transcriptions += daily.transcriptions
+		s.dailyStats[i].failed += daily.failed
+		s.dailyStats[i].audioDuration += daily.audioDuration
+	}
+
+	return int64(len(pruned)), nil
+}
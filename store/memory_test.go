@@ -0,0 +1,17 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("without_history", func(t *testing.T) {
+		storetest.TestRepository(t, store.NewMemoryStore())
+	})
+	t.Run("with_history", func(t *testing.T) {
+		storetest.TestRepository(t, store.NewMemoryStore(store.WithMemoryHistory()))
+	})
+}
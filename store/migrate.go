@@ -0,0 +1,137 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"go.uber.org/zap"
+)
+
+// Migrator changes the schema version of a connected store.
+type Migrator struct {
+	log *zap.Logger
+
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+type MigrationStatus struct {
+	// Version is the applied migration, 0 if there's none
+	Version uint
+	// Dirty is set if a migration failed partway, it has to be fixed by hand
+	// and then forced
+	Dirty bool
+	// Latest is the newest migration this build has
+	Latest uint
+}
+
+// Migrator returns a Migrator for the store's database. It must be closed
+// after use.
+func (s *Store) Migrator() (*Migrator, error) {
+	if s.backend == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	mFS, err := iofs.New(migrations, s.backend.migrationsDir())
+	if err != nil {
+		return nil, fmt.Errorf("creating iofs driver: %w", err)
+	}
+
+	databaseName, mDriver, err := s.backend.migrateDriver()
+	if err != nil {
+		return nil, fmt.Errorf("migrate driver instance: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", mFS, databaseName, mDriver)
+	if err != nil {
+		mDriver.Close()
+		return nil, fmt.Errorf("migrate instance: %w", err)
+	}
+
+	return &Migrator{
+		log:    s.log.Named("migrate"),
+		m:      m,
+		source: mFS,
+	}, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.m.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("getting version: %w", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	latest, err := m.source.First()
+	if err != nil {
+		return status, fmt.Errorf("reading migrations: %w", err)
+	}
+	for {
+		next, err := m.source.Next(latest)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		} else if err != nil {
+			return status, fmt.Errorf("reading migrations: %w", err)
+		}
+		latest = next
+	}
+	status.Latest = latest
+
+	return status, nil
+}
+
+// Up applies n migrations, or all of them if n is 0.
+func (m *Migrator) Up(n int) error {
+	var err error
+	if n == 0 {
+		err = m.m.Up()
+	} else {
+		err = m.m.Steps(n)
+	}
+	return m.logResult(err)
+}
+
+// Down rolls back n migrations. Rolling back everything drops all data, so
+// there's no shortcut for it.
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of migrations: %d", n)
+	}
+	return m.logResult(m.m.Steps(-n))
+}
+
+// Force sets the version without running migrations, clearing the dirty
+// flag. -1 means no migrations are applied.
+func (m *Migrator) Force(version int) error {
+	err := m.m.Force(version)
+	if err != nil {
+		return err
+	}
+	m.log.With(zap.Int("version", version)).Info("forced version")
+	return nil
+}
+
+func (m *Migrator) logResult(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		m.log.Info("migrations done (no change)")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("running migrations: %w", err)
+	}
+
+	version, _, _ := m.m.Version()
+	m.log.With(zap.Uint("version", version)).Info("migrations done")
+	return nil
+}
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX idx_asr_transcript_texts_user;
+DROP TABLE asr_transcript_texts;
+
+ALTER TABLE users
+    DROP COLUMN history_enabled,
+    DROP COLUMN history_enabled_touched_at;
+
+COMMIT;
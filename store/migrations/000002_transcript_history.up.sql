@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE users
+    ADD COLUMN history_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN history_enabled_touched_at timestamptz;
+
+CREATE TABLE asr_transcript_texts
+(
+    guild_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+    original_message_id TEXT NOT NULL,
+
+    user_id TEXT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+
+    -- pgp_sym_encrypt'd with the key from config, never stored in plaintext
+    text_encrypted BYTEA NOT NULL,
+
+    PRIMARY KEY(guild_id, channel_id, original_message_id),
+    FOREIGN KEY(guild_id, channel_id, original_message_id)
+        REFERENCES asr_transcriptions (guild_id, channel_id, original_message_id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_asr_transcript_texts_user
+ON asr_transcript_texts (user_id, created_at DESC);
+
+COMMIT;
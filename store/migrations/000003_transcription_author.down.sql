@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX idx_asr_transcriptions_author;
+
+ALTER TABLE asr_transcriptions
+    DROP COLUMN author_id;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE asr_transcriptions
+    ADD COLUMN author_id TEXT;
+
+UPDATE asr_transcriptions
+SET author_id=asr_transcript_texts.user_id
+FROM asr_transcript_texts
+WHERE
+    asr_transcriptions.guild_id=asr_transcript_texts.guild_id AND
+    asr_transcriptions.channel_id=asr_transcript_texts.channel_id AND
+    asr_transcriptions.original_message_id=asr_transcript_texts.original_message_id;
+
+CREATE INDEX idx_asr_transcriptions_author
+ON asr_transcriptions (author_id);
+
+COMMIT;
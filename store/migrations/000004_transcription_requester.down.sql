@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX idx_asr_transcriptions_requester;
+
+DROP INDEX idx_asr_transcriptions_author;
+
+CREATE INDEX idx_asr_transcriptions_author
+ON asr_transcriptions (author_id);
+
+ALTER TABLE asr_transcriptions
+    DROP COLUMN requester_id;
+
+COMMIT;
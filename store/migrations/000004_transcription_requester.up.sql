@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE asr_transcriptions
+    ADD COLUMN requester_id TEXT;
+
+-- so far, transcriptions were only ever triggered by the author sending the
+-- voice message
+UPDATE asr_transcriptions
+SET requester_id=author_id
+WHERE requester_id IS NULL AND author_id IS NOT NULL;
+
+DROP INDEX idx_asr_transcriptions_author;
+
+CREATE INDEX idx_asr_transcriptions_author
+ON asr_transcriptions (author_id, original_message_timestamp);
+
+CREATE INDEX idx_asr_transcriptions_requester
+ON asr_transcriptions (requester_id, original_message_timestamp);
+
+COMMIT;
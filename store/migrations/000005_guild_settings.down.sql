@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX idx_asr_transcriptions_guild_timestamp;
+DROP TABLE guild_settings;
+
+COMMIT;
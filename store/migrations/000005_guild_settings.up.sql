@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE guild_settings
+(
+    guild_id TEXT NOT NULL,
+
+    -- NULL uses the deployment's default
+    user_daily_audio_seconds FLOAT,
+    guild_daily_audio_seconds FLOAT,
+
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_by TEXT,
+
+    PRIMARY KEY(guild_id)
+);
+
+CREATE INDEX idx_asr_transcriptions_guild_timestamp
+ON asr_transcriptions (guild_id, original_message_timestamp);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE guild_settings
+DROP COLUMN low_confidence_action;
+
+DROP TYPE low_confidence_action;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+CREATE TYPE low_confidence_action AS ENUM ('flag', 'suppress');
+
+ALTER TABLE guild_settings
+ADD COLUMN low_confidence_action low_confidence_action NOT NULL DEFAULT 'flag';
+
+COMMIT;
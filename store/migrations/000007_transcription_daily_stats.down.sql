@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX idx_asr_transcriptions_timestamp;
+DROP TABLE asr_transcription_daily_stats;
+
+COMMIT;
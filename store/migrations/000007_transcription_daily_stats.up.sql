@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Aggregates of transcriptions pruned by the retention job, so stats still
+-- cover them. Processing time percentiles can't be combined and aren't kept.
+CREATE TABLE asr_transcription_daily_stats
+(
+    day DATE NOT NULL,
+    guild_id TEXT NOT NULL,
+    -- empty for transcriptions that never finished
+    transcription_model TEXT NOT NULL,
+
+    transcriptions BIGINT NOT NULL,
+    failed BIGINT NOT NULL,
+    audio_duration FLOAT NOT NULL,
+
+    PRIMARY KEY(day, guild_id, transcription_model)
+);
+
+CREATE INDEX idx_asr_transcriptions_timestamp
+ON asr_transcriptions (original_message_timestamp);
+
+COMMIT;
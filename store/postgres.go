@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/golang-migrate/migrate/v4/database"
+	migratePgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresStore is the Postgres backend, with queries generated from
+// queries.sql.
+type postgresStore struct {
+	conn *pgxpool.Pool
+
+	transcriptKey string
+
+	*db.Queries
+}
+
+func connectPostgres(ctx context.Context, dsn string, transcriptKey string) (*postgresStore, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dsn: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("opening postgres: %w", err)
+	}
+
+	return &postgresStore{
+		conn:          pool,
+		transcriptKey: transcriptKey,
+		Queries:       db.New(pool),
+	}, nil
+}
+
+func (s *postgresStore) Close() {
+	s.conn.Close()
+}
+
+func (s *postgresStore) Ping(ctx context.Context) error {
+	return s.conn.Ping(ctx)
+}
+
+func (s *postgresStore) migrationsDir() string {
+	return "migrations"
+}
+
+func (s *postgresStore) migrateDriver() (string, database.Driver, error) {
+	// closed with the migrate driver, which doesn't close the pool
+	stdDB := stdlib.OpenDBFromPool(s.conn)
+
+	mDriver, err := migratePgx.WithInstance(stdDB, &migratePgx.Config{})
+	if err != nil {
+		stdDB.Close()
+		return "", nil, err
+	}
+
+	return "pgx", mDriver, nil
+}
+
+func (s *postgresStore) UpdateTranscriptionDone(ctx context.Context, arg db.UpdateTranscriptionDoneParams) (db.AsrTranscription, error) {
+	transcription, err := s.Queries.UpdateTranscriptionDone(ctx, arg)
+	return transcription, notFound(err)
+}
+
+func (s *postgresStore) UpdateTranscriptionFailed(ctx context.Context, arg db.UpdateTranscriptionFailedParams) (db.AsrTranscription, error) {
+	transcription, err := s.Queries.UpdateTranscriptionFailed(ctx, arg)
+	return transcription, notFound(err)
+}
+
+func (s *postgresStore) UpdateTranscriptionMessageDeleted(ctx context.Context, arg db.UpdateTranscriptionMessageDeletedParams) (db.AsrTranscription, error) {
+	transcription, err := s.Queries.UpdateTranscriptionMessageDeleted(ctx, arg)
+	return transcription, notFound(err)
+}
@@ -0,0 +1,27 @@
+package store_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/storetest"
+)
+
+// postgresDSNEnv names a Postgres database to run the conformance suite
+// against. It's migrated, and transcriptions from before 2000 are pruned.
+const postgresDSNEnv = "ORANGE_TEST_POSTGRES_DSN"
+
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s isn't set", postgresDSNEnv)
+	}
+
+	t.Run("without_history", func(t *testing.T) {
+		storetest.TestRepository(t, connectTestStore(t, dsn))
+	})
+	t.Run("with_history", func(t *testing.T) {
+		storetest.TestRepository(t, connectTestStore(t, dsn, store.WithTranscriptKey("storetest")))
+	})
+}
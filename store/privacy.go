@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExportedUser struct {
+	ID                      string     `json:"id"`
+	ASREnabled              bool       `json:"asr_enabled"`
+	ASREnabledTouchedAt     *time.Time `json:"asr_enabled_touched_at"`
+	ASRNudged               bool       `json:"asr_nudged"`
+	ASRNudgedTouchedAt      *time.Time `json:"asr_nudged_touched_at"`
+	HistoryEnabled          bool       `json:"history_enabled"`
+	HistoryEnabledTouchedAt *time.Time `json:"history_enabled_touched_at"`
+}
+
+type ExportedTranscription struct {
+	GuildID                  string    `json:"guild_id"`
+	ChannelID                string    `json:"channel_id"`
+	OriginalMessageID        string    `json:"original_message_id"`
+	OriginalMessageDeleted   bool      `json:"original_message_deleted"`
+	OriginalMessageTimestamp time.Time `json:"original_message_timestamp"`
+	ResponseMessageID        string    `json:"response_message_id"`
+	ResponseDeleted          bool      `json:"response_deleted"`
+
+	// AuthorID sent the voice message, RequesterID had it transcribed
+	AuthorID    *string `json:"author_id"`
+	RequesterID *string `json:"requester_id"`
+
+	TranscriptionStatus         *string  `json:"transcription_status"`
+	VoiceMessageAudioDuration   *float64 `json:"voice_message_audio_duration"`
+	TranscriptionModel          *string  `json:"transcription_model"`
+	TranscriptionProcessingTime *float64 `json:"transcription_processing_time"`
+}
+
+type ExportedTranscript struct {
+	GuildID           string    `json:"guild_id"`
+	ChannelID         string    `json:"channel_id"`
+	OriginalMessageID string    `json:"original_message_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	Text              string    `json:"text"`
+}
+
+// UserDataExport is everything Orange stores about a user.
+type UserDataExport struct {
+	ExportedAt     time.Time               `json:"exported_at"`
+	User           *ExportedUser           `json:"user"`
+	Transcriptions []ExportedTranscription `json:"transcriptions"`
+	Transcripts    []ExportedTranscript    `json:"transcripts"`
+}
+
+// UserDataDeletion is what was deleted by DeleteUserData.
+type UserDataDeletion struct {
+	UserDeleted        bool
+	TranscriptsDeleted int64
+
+	// RequestsCleared are other users' transcriptions that had the user
+	// removed as the requester
+	RequestsCleared []db.AsrTranscription
+
+	// Transcriptions are the deleted rows, for cleaning up reply messages
+	Transcriptions []db.AsrTranscription
+}
+
+func timestamptzPointer(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func exportUser(user db.User) *ExportedUser {
+	return &ExportedUser{
+		ID:                      user.ID,
+		ASREnabled:              user.AsrEnabled,
+		ASREnabledTouchedAt:     timestamptzPointer(user.AsrEnabledTouchedAt),
+		ASRNudged:               user.AsrNudged,
+		ASRNudgedTouchedAt:      timestamptzPointer(user.AsrNudgedTouchedAt),
+		HistoryEnabled:          user.HistoryEnabled,
+		HistoryEnabledTouchedAt: timestamptzPointer(user.HistoryEnabledTouchedAt),
+	}
+}
+
+func exportTranscription(t db.AsrTranscription) ExportedTranscription {
+	exported := ExportedTranscription{
+		GuildID:                  t.GuildID,
+		ChannelID:                t.ChannelID,
+		OriginalMessageID:        t.OriginalMessageID,
+		OriginalMessageDeleted:   t.OriginalMessageDeleted,
+		OriginalMessageTimestamp: t.OriginalMessageTimestamp.Time,
+		ResponseMessageID:        t.ResponseMessageID,
+		ResponseDeleted:          t.ResponseDeleted,
+	}
+	if t.AuthorID.Valid {
+		exported.AuthorID = &t.AuthorID.String
+	}
+	if t.RequesterID.Valid {
+		exported.RequesterID = &t.RequesterID.String
+	}
+	if t.TranscriptionStatus.Valid {
+		status := string(t.TranscriptionStatus.TranscriptionStatus)
+		exported.TranscriptionStatus = &status
+	}
+	if t.VoiceMessageAudioDuration.Valid {
+		exported.VoiceMessageAudioDuration = &t.VoiceMessageAudioDuration.Float64
+	}
+	if t.TranscriptionModel.Valid {
+		exported.TranscriptionModel = &t.TranscriptionModel.String
+	}
+	if t.TranscriptionProcessingTime.Valid {
+		exported.TranscriptionProcessingTime = &t.TranscriptionProcessingTime.Float64
+	}
+	return exported
+}
+
+// ExportUserData collects every row about the user, including transcriptions
+// of voice messages they authored and decrypted transcript history.
+func (s *postgresStore) ExportUserData(ctx context.Context, userID string) (*UserDataExport, error) {
+	export := &UserDataExport{
+		ExportedAt:     time.Now().UTC(),
+		Transcriptions: []ExportedTranscription{},
+		Transcripts:    []ExportedTranscript{},
+	}
+
+	user, err := s.GetUsers(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("getting user: %w", err)
+	} else if err == nil {
+		export.User = exportUser(user)
+	}
+
+	transcriptions, err := s.ListTranscriptionsByAuthor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing transcriptions: %w", err)
+	}
+	for _, t := range transcriptions {
+		export.Transcriptions = append(export.Transcriptions, exportTranscription(t))
+	}
+
+	if s.HistoryAvailable() {
+		transcripts, err := s.ListAllTranscriptTexts(ctx, db.ListAllTranscriptTextsParams{
+			Key:    s.transcriptKey,
+			UserID: userID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing transcripts: %w", err)
+		}
+		for _, t := range transcripts {
+			export.Transcripts = append(export.Transcripts, ExportedTranscript{
+				GuildID:           t.GuildID,
+				ChannelID:         t.ChannelID,
+				OriginalMessageID: t.OriginalMessageID,
+				CreatedAt:         t.CreatedAt.Time,
+				Text:              t.Text,
+			})
+		}
+	}
+
+	return export, nil
+}
+
+// DeleteUserData deletes the user, their stored transcripts, and
+// transcriptions of voice messages they authored in one transaction. The user
+// is also removed as the requester of any remaining transcriptions.
+func (s *postgresStore) DeleteUserData(ctx context.Context, userID string) (*UserDataDeletion, error) {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.Queries.WithTx(tx)
+	deletion := &UserDataDeletion{}
+
+	deletion.TranscriptsDeleted, err = q.DeleteTranscriptTextsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting transcripts: %w", err)
+	}
+
+	deletion.Transcriptions, err = q.DeleteTranscriptionsByAuthor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting transcriptions: %w", err)
+	}
+
+	deletion.RequestsCleared, err = q.ClearTranscriptionsRequester(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("clearing requester: %w", err)
+	}
+
+	usersDeleted, err := q.DeleteUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting user: %w", err)
+	}
+	deletion.UserDeleted = usersDeleted > 0
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("committing: %w", err)
+	}
+
+	return deletion, nil
+}
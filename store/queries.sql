@@ -25,8 +25,10 @@ INSERT INTO asr_transcriptions (
     original_message_deleted,
     original_message_timestamp,
     response_message_id,
+    author_id,
+    requester_id,
     transcription_status
-) VALUES ($1, $2, $3, $4, $5, $6, 'started');
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'started');
 
 -- name: GetTranscriptionByOriginalMessage :one
 SELECT * FROM asr_transcriptions
@@ -74,4 +76,232 @@ WHERE
         original_message_id=sqlc.arg(message_id)::text OR 
         response_message_id=sqlc.arg(message_id)::text
     )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateUserHistoryEnabled :exec
+UPDATE users
+SET history_enabled=$1, history_enabled_touched_at=NOW()
+WHERE id=$2;
+
+-- name: CreateTranscriptText :exec
+INSERT INTO asr_transcript_texts (
+    guild_id,
+    channel_id,
+    original_message_id,
+    user_id,
+    text_encrypted
+) VALUES (
+    $1, $2, $3, $4,
+    pgp_sym_encrypt(sqlc.arg(text)::text, sqlc.arg(key)::text)
+);
+
+-- name: ListTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    pgp_sym_decrypt(text_encrypted, sqlc.arg(key)::text)::text AS text
+FROM asr_transcript_texts
+WHERE user_id=sqlc.arg(user_id)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: SearchTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    text
+FROM (
+    SELECT
+        guild_id,
+        channel_id,
+        original_message_id,
+        created_at,
+        pgp_sym_decrypt(text_encrypted, sqlc.arg(key)::text)::text AS text
+    FROM asr_transcript_texts
+    WHERE user_id=sqlc.arg(user_id)
+) decrypted
+WHERE to_tsvector('simple', text) @@ websearch_to_tsquery('simple', sqlc.arg(query)::text)
+ORDER BY
+    ts_rank(to_tsvector('simple', text), websearch_to_tsquery('simple', sqlc.arg(query)::text)) DESC,
+    created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+
+-- name: ListTranscriptionsByAuthor :many
+SELECT * FROM asr_transcriptions
+WHERE author_id=sqlc.arg(author_id)::text
+ORDER BY original_message_timestamp;
+
+-- name: GetRequesterTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='done') AS transcriptions_done,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration
+FROM asr_transcriptions
+WHERE
+    requester_id=sqlc.arg(requester_id)::text AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz;
+
+-- name: GetGuildTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='done') AS transcriptions_done,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration
+FROM asr_transcriptions
+WHERE
+    guild_id=sqlc.arg(guild_id)::text AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz;
+
+-- name: ListAllTranscriptTexts :many
+SELECT
+    guild_id,
+    channel_id,
+    original_message_id,
+    created_at,
+    pgp_sym_decrypt(text_encrypted, sqlc.arg(key)::text)::text AS text
+FROM asr_transcript_texts
+WHERE user_id=sqlc.arg(user_id)
+ORDER BY created_at;
+
+-- name: DeleteTranscriptTextsByUser :execrows
+DELETE FROM asr_transcript_texts
+WHERE user_id=$1;
+
+-- name: DeleteTranscriptionsByAuthor :many
+DELETE FROM asr_transcriptions
+WHERE author_id=sqlc.arg(author_id)::text
+RETURNING *;
+
+-- name: ClearTranscriptionsRequester :many
+UPDATE asr_transcriptions
+SET requester_id=NULL
+WHERE requester_id=sqlc.arg(requester_id)::text
+RETURNING *;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id=$1;
+
+-- name: GetGuildSettings :one
+SELECT * FROM guild_settings
+WHERE guild_id=$1 LIMIT 1;
+
+-- name: UpsertGuildSettings :one
+INSERT INTO guild_settings (
+    guild_id,
+    user_daily_audio_seconds,
+    guild_daily_audio_seconds,
+    low_confidence_action,
+    updated_by
+) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (guild_id) DO UPDATE
+SET
+    user_daily_audio_seconds=EXCLUDED.user_daily_audio_seconds,
+    guild_daily_audio_seconds=EXCLUDED.guild_daily_audio_seconds,
+    low_confidence_action=EXCLUDED.low_confidence_action,
+    updated_by=EXCLUDED.updated_by,
+    updated_at=NOW()
+RETURNING *;
+
+-- name: GetTranscriptionStatsTotal :one
+SELECT
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    (sqlc.narg(guild_id)::text IS NULL OR guild_id=sqlc.narg(guild_id)::text) AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz;
+
+-- name: GetTranscriptionStatsByModel :many
+SELECT
+    COALESCE(transcription_model, '')::text AS transcription_model,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    (sqlc.narg(guild_id)::text IS NULL OR guild_id=sqlc.narg(guild_id)::text) AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz
+GROUP BY transcription_model
+ORDER BY transcriptions DESC;
+
+-- name: GetTranscriptionStatsByDay :many
+SELECT
+    date_trunc('day', original_message_timestamp, 'UTC')::timestamptz AS day,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    (sqlc.narg(guild_id)::text IS NULL OR guild_id=sqlc.narg(guild_id)::text) AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz
+GROUP BY day
+ORDER BY day DESC;
+
+-- name: GetTranscriptionStatsByGuild :many
+SELECT
+    guild_id,
+    COUNT(*) AS transcriptions,
+    COUNT(*) FILTER (WHERE transcription_status='failed') AS failed,
+    COALESCE(SUM(voice_message_audio_duration), 0)::float8 AS audio_duration,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p50,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY transcription_processing_time), 0)::float8 AS processing_time_p95
+FROM asr_transcriptions
+WHERE
+    (sqlc.narg(guild_id)::text IS NULL OR guild_id=sqlc.narg(guild_id)::text) AND
+    original_message_timestamp >= sqlc.arg(since)::timestamptz
+GROUP BY guild_id
+ORDER BY transcriptions DESC;
+
+-- name: PruneTranscriptions :many
+DELETE FROM asr_transcriptions
+WHERE (guild_id, channel_id, original_message_id) IN (
+    SELECT guild_id, channel_id, original_message_id
+    FROM asr_transcriptions
+    WHERE
+        original_message_timestamp < sqlc.arg(before)::timestamptz AND
+        -- keep transcriptions in someone's /history
+        NOT EXISTS (
+            SELECT 1 FROM asr_transcript_texts
+            WHERE
+                asr_transcript_texts.guild_id=asr_transcriptions.guild_id AND
+                asr_transcript_texts.channel_id=asr_transcriptions.channel_id AND
+                asr_transcript_texts.original_message_id=asr_transcriptions.original_message_id
+        )
+    ORDER BY original_message_timestamp
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AddTranscriptionDailyStats :exec
+INSERT INTO asr_transcription_daily_stats (
+    day,
+    guild_id,
+    transcription_model,
+    transcriptions,
+    failed,
+    audio_duration
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (day, guild_id, transcription_model) DO UPDATE
+SET
+    transcriptions=asr_transcription_daily_stats.transcriptions+EXCLUDED.transcriptions,
+    failed=asr_transcription_daily_stats.failed+EXCLUDED.failed,
+    audio_duration=asr_transcription_daily_stats.audio_duration+EXCLUDED.audio_duration;
+
+-- name: ListTranscriptionDailyStats :many
+SELECT * FROM asr_transcription_daily_stats
+WHERE
+    (sqlc.narg(guild_id)::text IS NULL OR guild_id=sqlc.narg(guild_id)::text) AND
+    day >= sqlc.arg(since)::date;
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned when the row to get or update doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// notFound turns the drivers' no rows errors into ErrNotFound, so callers
+// don't depend on the backend.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// The repositories are what the bot needs from a store. Rows use the types
+// generated for Postgres, which every backend returns.
+
+type UserRepository interface {
+	// GetOrCreateUser returns the user, creating them with default
+	// preferences if they don't exist.
+	GetOrCreateUser(ctx context.Context, userID string) (*db.User, error)
+	UpdateUserASREnabled(ctx context.Context, arg db.UpdateUserASREnabledParams) error
+	UpdateUserASRNudge(ctx context.Context, arg db.UpdateUserASRNudgeParams) error
+	UpdateUserHistoryEnabled(ctx context.Context, arg db.UpdateUserHistoryEnabledParams) error
+}
+
+type TranscriptionRepository interface {
+	CreateStartedTranscription(ctx context.Context, arg db.CreateStartedTranscriptionParams) error
+	// UpdateTranscriptionDone, UpdateTranscriptionFailed and
+	// UpdateTranscriptionMessageDeleted return ErrNotFound if there's no
+	// matching transcription.
+	UpdateTranscriptionDone(ctx context.Context, arg db.UpdateTranscriptionDoneParams) (db.AsrTranscription, error)
+	UpdateTranscriptionFailed(ctx context.Context, arg db.UpdateTranscriptionFailedParams) (db.AsrTranscription, error)
+	// UpdateTranscriptionMessageDeleted marks the original message or the
+	// response as deleted, depending on which one MessageID is.
+	UpdateTranscriptionMessageDeleted(ctx context.Context, arg db.UpdateTranscriptionMessageDeletedParams) (db.AsrTranscription, error)
+
+	GetRequesterTranscriptionTotals(ctx context.Context, arg db.GetRequesterTranscriptionTotalsParams) (db.GetRequesterTranscriptionTotalsRow, error)
+	GetGuildTranscriptionTotals(ctx context.Context, arg db.GetGuildTranscriptionTotalsParams) (db.GetGuildTranscriptionTotalsRow, error)
+	GetUsageStats(ctx context.Context, guildID string, since time.Time, pricesPerMinute map[string]float64) (*UsageStats, error)
+}
+
+type GuildSettingsRepository interface {
+	GetGuildSettingsOrDefault(ctx context.Context, guildID string) (*db.GuildSetting, error)
+	UpsertGuildSettings(ctx context.Context, arg db.UpsertGuildSettingsParams) (db.GuildSetting, error)
+}
+
+type HistoryRepository interface {
+	HistoryAvailable() bool
+	StoreTranscriptText(ctx context.Context, transcription *db.AsrTranscription, userID string, text string) error
+	GetTranscriptHistory(ctx context.Context, userID string, query string, limit, offset int32) ([]TranscriptHistoryEntry, error)
+}
+
+type PrivacyRepository interface {
+	ExportUserData(ctx context.Context, userID string) (*UserDataExport, error)
+	DeleteUserData(ctx context.Context, userID string) (*UserDataDeletion, error)
+}
+
+type RetentionRepository interface {
+	// PruneTranscriptions deletes up to limit of the oldest transcriptions
+	// from before the given time and adds them to the daily stats. Ones with a
+	// stored transcript are kept for /history until the user deletes their
+	// data. It returns how many were deleted.
+	PruneTranscriptions(ctx context.Context, before time.Time, limit int32) (int64, error)
+}
+
+// Repository is everything the bot stores.
+type Repository interface {
+	UserRepository
+	TranscriptionRepository
+	GuildSettingsRepository
+	HistoryRepository
+	PrivacyRepository
+	RetentionRepository
+}
+
+var (
+	_ Repository = (*Store)(nil)
+	_ Repository = (*postgresStore)(nil)
+	_ Repository = (*sqliteStore)(nil)
+	_ Repository = (*MemoryStore)(nil)
+)
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/K3das/orange/metrics"
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// pruneBatchPause is how long the retention job waits between batches, so
+// other queries get the database in between.
+const pruneBatchPause = time.Second
+
+type RetentionOptions struct {
+	// days transcriptions are kept before they're pruned into daily stats, 0
+	// keeps them forever
+	Days int `env:"DAYS"`
+	// how often to look for transcriptions to prune
+	Interval time.Duration `env:"INTERVAL" envDefault:"1h"`
+	// transcriptions deleted per transaction
+	BatchSize int32 `env:"BATCH_SIZE" envDefault:"500"`
+}
+
+// RunRetention prunes transcriptions older than the retention window every
+// interval until ctx is done. Failed runs are logged and retried on the next
+// interval.
+func (s *Store) RunRetention(ctx context.Context, options RetentionOptions) error {
+	log := s.log.Named("retention")
+
+	if options.Days <= 0 {
+		log.Info("retention disabled, keeping transcriptions forever")
+		return nil
+	}
+	if options.Interval <= 0 || options.BatchSize <= 0 {
+		return fmt.Errorf("retention interval and batch size must be positive")
+	}
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().AddDate(0, 0, -options.Days)
+		pruned, err := s.prune(ctx, before, options.BatchSize)
+
+		runLog := log.With(
+			zap.Time("before", before),
+			zap.Int64("pruned", pruned),
+		)
+		if err != nil && ctx.Err() == nil {
+			runLog.Error("failed to prune transcriptions", zap.Error(err))
+		} else if pruned > 0 {
+			runLog.Info("pruned transcriptions")
+		} else {
+			runLog.Debug("no transcriptions to prune")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune deletes transcriptions from before the given time in batches until
+// there are none left, returning how many it deleted.
+func (s *Store) prune(ctx context.Context, before time.Time, batchSize int32) (int64, error) {
+	var total int64
+	for {
+		pruned, err := s.backend.PruneTranscriptions(ctx, before, batchSize)
+		total += pruned
+		metrics.TranscriptionsPruned.Add(float64(pruned))
+		if err != nil {
+			return total, err
+		}
+		if pruned < int64(batchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(pruneBatchPause):
+		}
+	}
+}
+
+// dailyStats is a rollup of a guild's pruned transcriptions with one model
+// on a day, which stats add to the remaining transcriptions.
+type dailyStats struct {
+	// day is YYYY-MM-DD in UTC
+	day     string
+	guildID string
+	// model is empty for transcriptions that never finished
+	model string
+
+	transcriptions int64
+	failed         int64
+	audioDuration  float64
+}
+
+// rollUpTranscriptions groups transcriptions into daily stats.
+func rollUpTranscriptions(transcriptions []db.AsrTranscription) []dailyStats {
+	type rollupKey struct {
+		day     string
+		guildID string
+		model   string
+	}
+
+	var daily []dailyStats
+	index := map[rollupKey]int{}
+	for _, transcription := range transcriptions {
+		key := rollupKey{
+			day:     transcription.OriginalMessageTimestamp.Time.UTC().Format(time.DateOnly),
+			guildID: transcription.GuildID,
+			model:   transcription.TranscriptionModel.String,
+		}
+		i, ok := index[key]
+		if !ok {
+			daily = append(daily, dailyStats{
+				day:     key.day,
+				guildID: key.guildID,
+				model:   key.model,
+			})
+			i = len(daily) - 1
+			index[key] = i
+		}
+
+		daily[i].transcriptions++
+		if transcription.TranscriptionStatus.TranscriptionStatus == db.TranscriptionStatusFailed {
+			daily[i].failed++
+		}
+		daily[i].audioDuration += transcription.VoiceMessageAudioDuration.Float64
+	}
+
+	return daily
+}
+
+// PruneTranscriptions deletes one batch in a transaction, skipping rows
+// locked by other transactions.
+func (s *postgresStore) PruneTranscriptions(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.Queries.WithTx(tx)
+
+	pruned, err := q.PruneTranscriptions(ctx, db.PruneTranscriptionsParams{
+		Before: pgtype.Timestamptz{
+			Time:  before,
+			Valid: true,
+		},
+		RowLimit: limit,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("deleting transcriptions: %w", err)
+	}
+
+	for _, daily := range rollUpTranscriptions(pruned) {
+		day, err := time.Parse(time.DateOnly, daily.day)
+		if err != nil {
+			return 0, fmt.Errorf("parsing day: %w", err)
+		}
+		err = q.AddTranscriptionDailyStats(ctx, db.AddTranscriptionDailyStatsParams{
+			Day: pgtype.Date{
+				Time:  day,
+				Valid: true,
+			},
+			GuildID:            daily.guildID,
+			TranscriptionModel: daily.model,
+			Transcriptions:     daily.transcriptions,
+			Failed:             daily.failed,
+			AudioDuration:      daily.audioDuration,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("adding daily stats: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("committing: %w", err)
+	}
+
+	return int64(len(pruned)), nil
+}
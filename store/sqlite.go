@@ -0,0 +1,263 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	sqlitedb "github.com/K3das/orange/store/sqlite/db"
+	"github.com/golang-migrate/migrate/v4/database"
+	migrateSQLite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteScheme = "sqlite:"
+
+// sqlitePragmas are set on every connection. Foreign keys are needed for
+// transcripts to be deleted with their transcription.
+var sqlitePragmas = []string{
+	"foreign_keys(1)",
+	"busy_timeout(5000)",
+	"journal_mode(WAL)",
+}
+
+// sqliteDSNPath returns the file of a sqlite: DSN, such as
+// sqlite:///var/lib/orange/orange.db or sqlite://orange.db for a relative
+// path. Query parameters are passed to the driver.
+func sqliteDSNPath(dsn string) (string, bool) {
+	path, ok := strings.CutPrefix(dsn, sqliteScheme)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(path, "//"), true
+}
+
+// sqliteStore is the SQLite backend, with queries generated from
+// sqlite/queries.sql. Timestamps are stored as unix microseconds and
+// transcripts are encrypted with AES-GCM.
+type sqliteStore struct {
+	db  *sql.DB
+	dsn string
+
+	// transcriptCipher is nil if history is unavailable
+	transcriptCipher cipher.AEAD
+
+	q *sqlitedb.Queries
+}
+
+func sqliteDriverDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=" + strings.Join(sqlitePragmas, "&_pragma=")
+}
+
+func connectSQLite(ctx context.Context, path string, transcriptKey string) (*sqliteStore, error) {
+	s := &sqliteStore{
+		dsn: sqliteDriverDSN(path),
+	}
+
+	if transcriptKey != "" {
+		// the key is hashed into one of the right size, like pgcrypto it's
+		// expected to be a long random secret
+		key := sha256.Sum256([]byte(transcriptKey))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, fmt.Errorf("creating transcript cipher: %w", err)
+		}
+		s.transcriptCipher, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("creating transcript cipher: %w", err)
+		}
+	}
+
+	conn, err := sql.Open("sqlite", s.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite: %w", err)
+	}
+	// SQLite has a single writer, sharing one connection avoids busy errors
+	// between the bot's goroutines
+	conn.SetMaxOpenConns(1)
+
+	err = conn.PingContext(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("opening sqlite: %w", err)
+	}
+
+	s.db = conn
+	s.q = sqlitedb.New(conn)
+
+	return s, nil
+}
+
+func (s *sqliteStore) Close() {
+	s.db.Close()
+}
+
+func (s *sqliteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *sqliteStore) migrationsDir() string {
+	return "sqlite/migrations"
+}
+
+func (s *sqliteStore) migrateDriver() (string, database.Driver, error) {
+	// the migrate driver closes its database, so it gets its own
+	conn, err := sql.Open("sqlite", s.dsn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mDriver, err := migrateSQLite.WithInstance(conn, &migrateSQLite.Config{})
+	if err != nil {
+		conn.Close()
+		return "", nil, err
+	}
+
+	return "sqlite", mDriver, nil
+}
+
+func (s *sqliteStore) collector() prometheus.Collector {
+	return collectors.NewDBStatsCollector(s.db, "sqlite")
+}
+
+func sqliteTime(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func sqliteNow() int64 {
+	return sqliteTime(time.Now())
+}
+
+func sqliteTimestamptz(micros int64) pgtype.Timestamptz {
+	return pgtype.Timestamptz{
+		Time:  time.UnixMicro(micros),
+		Valid: true,
+	}
+}
+
+func sqliteNullTimestamptz(micros sql.NullInt64) pgtype.Timestamptz {
+	if !micros.Valid {
+		return pgtype.Timestamptz{}
+	}
+	return sqliteTimestamptz(micros.Int64)
+}
+
+func sqliteText(s sql.NullString) pgtype.Text {
+	return pgtype.Text{String: s.String, Valid: s.Valid}
+}
+
+func sqliteNullString(t pgtype.Text) sql.NullString {
+	return sql.NullString{String: t.String, Valid: t.Valid}
+}
+
+func sqliteFloat8(f sql.NullFloat64) pgtype.Float8 {
+	return pgtype.Float8{Float64: f.Float64, Valid: f.Valid}
+}
+
+func sqliteNullFloat64(f pgtype.Float8) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f.Float64, Valid: f.Valid}
+}
+
+func sqliteUser(user sqlitedb.User) db.User {
+	return db.User{
+		ID:                      user.ID,
+		AsrEnabled:              user.AsrEnabled,
+		AsrEnabledTouchedAt:     sqliteNullTimestamptz(user.AsrEnabledTouchedAt),
+		AsrNudged:               user.AsrNudged,
+		AsrNudgedTouchedAt:      sqliteNullTimestamptz(user.AsrNudgedTouchedAt),
+		HistoryEnabled:          user.HistoryEnabled,
+		HistoryEnabledTouchedAt: sqliteNullTimestamptz(user.HistoryEnabledTouchedAt),
+	}
+}
+
+func sqliteTranscription(t sqlitedb.AsrTranscription) db.AsrTranscription {
+	return db.AsrTranscription{
+		GuildID:                  t.GuildID,
+		ChannelID:                t.ChannelID,
+		OriginalMessageID:        t.OriginalMessageID,
+		OriginalMessageDeleted:   t.OriginalMessageDeleted,
+		OriginalMessageTimestamp: sqliteTimestamptz(t.OriginalMessageTimestamp),
+		ResponseMessageID:        t.ResponseMessageID,
+		ResponseDeleted:          t.ResponseDeleted,
+		TranscriptionStatus: db.NullTranscriptionStatus{
+			TranscriptionStatus: db.TranscriptionStatus(t.TranscriptionStatus.String),
+			Valid:               t.TranscriptionStatus.Valid,
+		},
+		VoiceMessageAudioDuration:   sqliteFloat8(t.VoiceMessageAudioDuration),
+		TranscriptionModel:          sqliteText(t.TranscriptionModel),
+		TranscriptionProcessingTime: sqliteFloat8(t.TranscriptionProcessingTime),
+		AuthorID:                    sqliteText(t.AuthorID),
+		RequesterID:                 sqliteText(t.RequesterID),
+	}
+}
+
+func sqliteTranscriptions(rows []sqlitedb.AsrTranscription) []db.AsrTranscription {
+	transcriptions := make([]db.AsrTranscription, 0, len(rows))
+	for _, row := range rows {
+		transcriptions = append(transcriptions, sqliteTranscription(row))
+	}
+	return transcriptions
+}
+
+func sqliteGuildSetting(settings sqlitedb.GuildSetting) db.GuildSetting {
+	return db.GuildSetting{
+		GuildID:                settings.GuildID,
+		UserDailyAudioSeconds:  sqliteFloat8(settings.UserDailyAudioSeconds),
+		GuildDailyAudioSeconds: sqliteFloat8(settings.GuildDailyAudioSeconds),
+		UpdatedAt:              sqliteTimestamptz(settings.UpdatedAt),
+		UpdatedBy:              sqliteText(settings.UpdatedBy),
+		LowConfidenceAction:    db.LowConfidenceAction(settings.LowConfidenceAction),
+	}
+}
+
+func (s *sqliteStore) encryptTranscript(text string) ([]byte, error) {
+	nonce := make([]byte, s.transcriptCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return s.transcriptCipher.Seal(nonce, nonce, []byte(text), nil), nil
+}
+
+func (s *sqliteStore) decryptTranscript(encrypted []byte) (string, error) {
+	nonceSize := s.transcriptCipher.NonceSize()
+	if len(encrypted) < nonceSize {
+		return "", fmt.Errorf("transcript too short")
+	}
+	text, err := s.transcriptCipher.Open(nil, encrypted[:nonceSize], encrypted[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypting transcript: %w", err)
+	}
+	return string(text), nil
+}
+
+func (s *sqliteStore) decryptTranscripts(rows []sqlitedb.AsrTranscriptText) ([]TranscriptHistoryEntry, error) {
+	entries := make([]TranscriptHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		text, err := s.decryptTranscript(row.TextEncrypted)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, TranscriptHistoryEntry{
+			GuildID:           row.GuildID,
+			ChannelID:         row.ChannelID,
+			OriginalMessageID: row.OriginalMessageID,
+			CreatedAt:         time.UnixMicro(row.CreatedAt),
+			Text:              text,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,62 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+
+package db
+
+import (
+	"database/sql"
+)
+
+type AsrTranscriptText struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	UserID            string
+	CreatedAt         int64
+	TextEncrypted     []byte
+}
+
+type AsrTranscription struct {
+	GuildID                     string
+	ChannelID                   string
+	OriginalMessageID           string
+	OriginalMessageDeleted      bool
+	OriginalMessageTimestamp    int64
+	ResponseMessageID           string
+	ResponseDeleted             bool
+	TranscriptionStatus         sql.NullString
+	VoiceMessageAudioDuration   sql.NullFloat64
+	TranscriptionModel          sql.NullString
+	TranscriptionProcessingTime sql.NullFloat64
+	AuthorID                    sql.NullString
+	RequesterID                 sql.NullString
+}
+
+type AsrTranscriptionDailyStat struct {
+	Day                string
+	GuildID            string
+	TranscriptionModel string
+	Transcriptions     int64
+	Failed             int64
+	AudioDuration      float64
+}
+
+type GuildSetting struct {
+	GuildID                string
+	UserDailyAudioSeconds  sql.NullFloat64
+	GuildDailyAudioSeconds sql.NullFloat64
+	UpdatedAt              int64
+	UpdatedBy              sql.NullString
+	LowConfidenceAction    string
+}
+
+type User struct {
+	ID                      string
+	AsrEnabled              bool
+	AsrEnabledTouchedAt     sql.NullInt64
+	AsrNudged               bool
+	AsrNudgedTouchedAt      sql.NullInt64
+	HistoryEnabled          bool
+	HistoryEnabledTouchedAt sql.NullInt64
+}
@@ -0,0 +1,850 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: queries.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const addTranscriptionDailyStats = `-- name: AddTranscriptionDailyStats :exec
+INSERT INTO asr_transcription_daily_stats (
+    day,
+    guild_id,
+    transcription_model,
+    transcriptions,
+    failed,
+    audio_duration
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (day, guild_id, transcription_model) DO UPDATE
+SET
+    transcriptions=transcriptions+excluded.transcriptions,
+    failed=failed+excluded.failed,
+    audio_duration=audio_duration+excluded.audio_duration
+`
+
+type AddTranscriptionDailyStatsParams struct {
+	Day                string
+	GuildID            string
+	TranscriptionModel string
+	Transcriptions     int64
+	Failed             int64
+	AudioDuration      float64
+}
+
+func (q *Queries) AddTranscriptionDailyStats(ctx context.Context, arg AddTranscriptionDailyStatsParams) error {
+	_, err := q.db.ExecContext(ctx, addTranscriptionDailyStats,
+		arg.Day,
+		arg.GuildID,
+		arg.TranscriptionModel,
+		arg.Transcriptions,
+		arg.Failed,
+		arg.AudioDuration,
+	)
+	return err
+}
+
+const clearTranscriptionsRequester = `-- name: ClearTranscriptionsRequester :many
+UPDATE asr_transcriptions
+SET requester_id=NULL
+WHERE requester_id=CAST(?1 AS TEXT)
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+func (q *Queries) ClearTranscriptionsRequester(ctx context.Context, requesterID string) ([]AsrTranscription, error) {
+	rows, err := q.db.QueryContext(ctx, clearTranscriptionsRequester, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createStartedTranscription = `-- name: CreateStartedTranscription :exec
+INSERT INTO asr_transcriptions (
+    guild_id,
+    channel_id,
+    original_message_id,
+    original_message_deleted,
+    original_message_timestamp,
+    response_message_id,
+    author_id,
+    requester_id,
+    transcription_status
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'started')
+`
+
+type CreateStartedTranscriptionParams struct {
+	GuildID                  string
+	ChannelID                string
+	OriginalMessageID        string
+	OriginalMessageDeleted   bool
+	OriginalMessageTimestamp int64
+	ResponseMessageID        string
+	AuthorID                 sql.NullString
+	RequesterID              sql.NullString
+}
+
+func (q *Queries) CreateStartedTranscription(ctx context.Context, arg CreateStartedTranscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, createStartedTranscription,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.OriginalMessageID,
+		arg.OriginalMessageDeleted,
+		arg.OriginalMessageTimestamp,
+		arg.ResponseMessageID,
+		arg.AuthorID,
+		arg.RequesterID,
+	)
+	return err
+}
+
+const createTranscriptText = `-- name: CreateTranscriptText :exec
+INSERT INTO asr_transcript_texts (
+    guild_id,
+    channel_id,
+    original_message_id,
+    user_id,
+    created_at,
+    text_encrypted
+) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateTranscriptTextParams struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+	UserID            string
+	CreatedAt         int64
+	TextEncrypted     []byte
+}
+
+func (q *Queries) CreateTranscriptText(ctx context.Context, arg CreateTranscriptTextParams) error {
+	_, err := q.db.ExecContext(ctx, createTranscriptText,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.OriginalMessageID,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.TextEncrypted,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users (id)
+VALUES (?)
+ON CONFLICT (id) DO NOTHING
+`
+
+func (q *Queries) CreateUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, createUser, id)
+	return err
+}
+
+const deleteTranscriptTextsByUser = `-- name: DeleteTranscriptTextsByUser :execrows
+DELETE FROM asr_transcript_texts
+WHERE user_id=?
+`
+
+func (q *Queries) DeleteTranscriptTextsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTranscriptTextsByUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTranscriptionsByAuthor = `-- name: DeleteTranscriptionsByAuthor :many
+DELETE FROM asr_transcriptions
+WHERE author_id=CAST(?1 AS TEXT)
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+func (q *Queries) DeleteTranscriptionsByAuthor(ctx context.Context, authorID string) ([]AsrTranscription, error) {
+	rows, err := q.db.QueryContext(ctx, deleteTranscriptionsByAuthor, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id=?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getGuildSettings = `-- name: GetGuildSettings :one
+SELECT guild_id, user_daily_audio_seconds, guild_daily_audio_seconds, updated_at, updated_by, low_confidence_action FROM guild_settings
+WHERE guild_id=? LIMIT 1
+`
+
+func (q *Queries) GetGuildSettings(ctx context.Context, guildID string) (GuildSetting, error) {
+	row := q.db.QueryRowContext(ctx, getGuildSettings, guildID)
+	var i GuildSetting
+	err := row.Scan(
+		&i.GuildID,
+		&i.UserDailyAudioSeconds,
+		&i.GuildDailyAudioSeconds,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.LowConfidenceAction,
+	)
+	return i, err
+}
+
+const getGuildTranscriptionTotals = `-- name: GetGuildTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    CAST(COALESCE(SUM(transcription_status='done'), 0) AS INTEGER) AS transcriptions_done,
+    CAST(COALESCE(SUM(voice_message_audio_duration), 0) AS REAL) AS audio_duration
+FROM asr_transcriptions
+WHERE
+    guild_id=?1 AND
+    original_message_timestamp >= ?2
+`
+
+type GetGuildTranscriptionTotalsParams struct {
+	GuildID string
+	Since   int64
+}
+
+type GetGuildTranscriptionTotalsRow struct {
+	Transcriptions     int64
+	TranscriptionsDone int64
+	AudioDuration      float64
+}
+
+func (q *Queries) GetGuildTranscriptionTotals(ctx context.Context, arg GetGuildTranscriptionTotalsParams) (GetGuildTranscriptionTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getGuildTranscriptionTotals, arg.GuildID, arg.Since)
+	var i GetGuildTranscriptionTotalsRow
+	err := row.Scan(&i.Transcriptions, &i.TranscriptionsDone, &i.AudioDuration)
+	return i, err
+}
+
+const getRequesterTranscriptionTotals = `-- name: GetRequesterTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    CAST(COALESCE(SUM(transcription_status='done'), 0) AS INTEGER) AS transcriptions_done,
+    CAST(COALESCE(SUM(voice_message_audio_duration), 0) AS REAL) AS audio_duration
+FROM asr_transcriptions
+WHERE
+    requester_id=CAST(?1 AS TEXT) AND
+    original_message_timestamp >= ?2
+`
+
+type GetRequesterTranscriptionTotalsParams struct {
+	RequesterID string
+	Since       int64
+}
+
+type GetRequesterTranscriptionTotalsRow struct {
+	Transcriptions     int64
+	TranscriptionsDone int64
+	AudioDuration      float64
+}
+
+func (q *Queries) GetRequesterTranscriptionTotals(ctx context.Context, arg GetRequesterTranscriptionTotalsParams) (GetRequesterTranscriptionTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getRequesterTranscriptionTotals, arg.RequesterID, arg.Since)
+	var i GetRequesterTranscriptionTotalsRow
+	err := row.Scan(&i.Transcriptions, &i.TranscriptionsDone, &i.AudioDuration)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, asr_enabled, asr_enabled_touched_at, asr_nudged, asr_nudged_touched_at, history_enabled, history_enabled_touched_at FROM users
+WHERE id=? LIMIT 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.AsrEnabled,
+		&i.AsrEnabledTouchedAt,
+		&i.AsrNudged,
+		&i.AsrNudgedTouchedAt,
+		&i.HistoryEnabled,
+		&i.HistoryEnabledTouchedAt,
+	)
+	return i, err
+}
+
+const listAllTranscriptTexts = `-- name: ListAllTranscriptTexts :many
+SELECT guild_id, channel_id, original_message_id, user_id, created_at, text_encrypted FROM asr_transcript_texts
+WHERE user_id=?1
+ORDER BY created_at
+`
+
+func (q *Queries) ListAllTranscriptTexts(ctx context.Context, userID string) ([]AsrTranscriptText, error) {
+	rows, err := q.db.QueryContext(ctx, listAllTranscriptTexts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscriptText
+	for rows.Next() {
+		var i AsrTranscriptText
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.TextEncrypted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptTexts = `-- name: ListTranscriptTexts :many
+SELECT guild_id, channel_id, original_message_id, user_id, created_at, text_encrypted FROM asr_transcript_texts
+WHERE user_id=?1
+ORDER BY created_at DESC
+LIMIT ?3 OFFSET ?2
+`
+
+type ListTranscriptTextsParams struct {
+	UserID    string
+	RowOffset int64
+	RowLimit  int64
+}
+
+func (q *Queries) ListTranscriptTexts(ctx context.Context, arg ListTranscriptTextsParams) ([]AsrTranscriptText, error) {
+	rows, err := q.db.QueryContext(ctx, listTranscriptTexts, arg.UserID, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscriptText
+	for rows.Next() {
+		var i AsrTranscriptText
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.TextEncrypted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptionDailyStats = `-- name: ListTranscriptionDailyStats :many
+SELECT day, guild_id, transcription_model, transcriptions, failed, audio_duration FROM asr_transcription_daily_stats
+WHERE
+    (CAST(?1 AS TEXT) IS NULL OR guild_id=CAST(?1 AS TEXT)) AND
+    day >= CAST(?2 AS TEXT)
+`
+
+type ListTranscriptionDailyStatsParams struct {
+	GuildID sql.NullString
+	Since   string
+}
+
+func (q *Queries) ListTranscriptionDailyStats(ctx context.Context, arg ListTranscriptionDailyStatsParams) ([]AsrTranscriptionDailyStat, error) {
+	rows, err := q.db.QueryContext(ctx, listTranscriptionDailyStats, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscriptionDailyStat
+	for rows.Next() {
+		var i AsrTranscriptionDailyStat
+		if err := rows.Scan(
+			&i.Day,
+			&i.GuildID,
+			&i.TranscriptionModel,
+			&i.Transcriptions,
+			&i.Failed,
+			&i.AudioDuration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptionsByAuthor = `-- name: ListTranscriptionsByAuthor :many
+SELECT guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id FROM asr_transcriptions
+WHERE author_id=CAST(?1 AS TEXT)
+ORDER BY original_message_timestamp
+`
+
+func (q *Queries) ListTranscriptionsByAuthor(ctx context.Context, authorID string) ([]AsrTranscription, error) {
+	rows, err := q.db.QueryContext(ctx, listTranscriptionsByAuthor, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptionsSince = `-- name: ListTranscriptionsSince :many
+SELECT guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id FROM asr_transcriptions
+WHERE
+    (CAST(?1 AS TEXT) IS NULL OR guild_id=CAST(?1 AS TEXT)) AND
+    original_message_timestamp >= ?2
+`
+
+type ListTranscriptionsSinceParams struct {
+	GuildID sql.NullString
+	Since   int64
+}
+
+func (q *Queries) ListTranscriptionsSince(ctx context.Context, arg ListTranscriptionsSinceParams) ([]AsrTranscription, error) {
+	rows, err := q.db.QueryContext(ctx, listTranscriptionsSince, arg.GuildID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneTranscriptions = `-- name: PruneTranscriptions :many
+DELETE FROM asr_transcriptions
+WHERE rowid IN (
+    SELECT pruned.rowid
+    FROM asr_transcriptions AS pruned
+    WHERE
+        pruned.original_message_timestamp < ?1 AND
+        -- keep transcriptions in someone's /history
+        NOT EXISTS (
+            SELECT 1 FROM asr_transcript_texts
+            WHERE
+                asr_transcript_texts.guild_id=pruned.guild_id AND
+                asr_transcript_texts.channel_id=pruned.channel_id AND
+                asr_transcript_texts.original_message_id=pruned.original_message_id
+        )
+    ORDER BY pruned.original_message_timestamp
+    LIMIT ?2
+)
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+type PruneTranscriptionsParams struct {
+	Before   int64
+	RowLimit int64
+}
+
+func (q *Queries) PruneTranscriptions(ctx context.Context, arg PruneTranscriptionsParams) ([]AsrTranscription, error) {
+	rows, err := q.db.QueryContext(ctx, pruneTranscriptions, arg.Before, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AsrTranscription
+	for rows.Next() {
+		var i AsrTranscription
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.ChannelID,
+			&i.OriginalMessageID,
+			&i.OriginalMessageDeleted,
+			&i.OriginalMessageTimestamp,
+			&i.ResponseMessageID,
+			&i.ResponseDeleted,
+			&i.TranscriptionStatus,
+			&i.VoiceMessageAudioDuration,
+			&i.TranscriptionModel,
+			&i.TranscriptionProcessingTime,
+			&i.AuthorID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTranscriptionDone = `-- name: UpdateTranscriptionDone :one
+UPDATE asr_transcriptions
+SET
+    transcription_status='done',
+    voice_message_audio_duration=?1,
+    transcription_model=?2,
+    transcription_processing_time=?3
+WHERE
+    guild_id=?4 AND
+    channel_id=?5 AND
+    original_message_id=?6
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+type UpdateTranscriptionDoneParams struct {
+	VoiceMessageAudioDuration   sql.NullFloat64
+	TranscriptionModel          sql.NullString
+	TranscriptionProcessingTime sql.NullFloat64
+	GuildID                     string
+	ChannelID                   string
+	OriginalMessageID           string
+}
+
+func (q *Queries) UpdateTranscriptionDone(ctx context.Context, arg UpdateTranscriptionDoneParams) (AsrTranscription, error) {
+	row := q.db.QueryRowContext(ctx, updateTranscriptionDone,
+		arg.VoiceMessageAudioDuration,
+		arg.TranscriptionModel,
+		arg.TranscriptionProcessingTime,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.OriginalMessageID,
+	)
+	var i AsrTranscription
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.OriginalMessageID,
+		&i.OriginalMessageDeleted,
+		&i.OriginalMessageTimestamp,
+		&i.ResponseMessageID,
+		&i.ResponseDeleted,
+		&i.TranscriptionStatus,
+		&i.VoiceMessageAudioDuration,
+		&i.TranscriptionModel,
+		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
+	)
+	return i, err
+}
+
+const updateTranscriptionFailed = `-- name: UpdateTranscriptionFailed :one
+UPDATE asr_transcriptions
+SET
+    transcription_status='failed'
+WHERE
+    guild_id=?1 AND
+    channel_id=?2 AND
+    original_message_id=?3
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+type UpdateTranscriptionFailedParams struct {
+	GuildID           string
+	ChannelID         string
+	OriginalMessageID string
+}
+
+func (q *Queries) UpdateTranscriptionFailed(ctx context.Context, arg UpdateTranscriptionFailedParams) (AsrTranscription, error) {
+	row := q.db.QueryRowContext(ctx, updateTranscriptionFailed, arg.GuildID, arg.ChannelID, arg.OriginalMessageID)
+	var i AsrTranscription
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.OriginalMessageID,
+		&i.OriginalMessageDeleted,
+		&i.OriginalMessageTimestamp,
+		&i.ResponseMessageID,
+		&i.ResponseDeleted,
+		&i.TranscriptionStatus,
+		&i.VoiceMessageAudioDuration,
+		&i.TranscriptionModel,
+		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
+	)
+	return i, err
+}
+
+const updateTranscriptionMessageDeleted = `-- name: UpdateTranscriptionMessageDeleted :one
+UPDATE asr_transcriptions
+SET
+    response_deleted=
+        CASE WHEN response_message_id=?1 THEN TRUE ELSE response_deleted END,
+
+    original_message_deleted=
+        CASE WHEN original_message_id=?1 THEN TRUE ELSE original_message_deleted END
+WHERE
+    guild_id=?2 AND
+    channel_id=?3 AND
+    (
+        original_message_id=?1 OR
+        response_message_id=?1
+    )
+RETURNING guild_id, channel_id, original_message_id, original_message_deleted, original_message_timestamp, response_message_id, response_deleted, transcription_status, voice_message_audio_duration, transcription_model, transcription_processing_time, author_id, requester_id
+`
+
+type UpdateTranscriptionMessageDeletedParams struct {
+	MessageID string
+	GuildID   string
+	ChannelID string
+}
+
+func (q *Queries) UpdateTranscriptionMessageDeleted(ctx context.Context, arg UpdateTranscriptionMessageDeletedParams) (AsrTranscription, error) {
+	row := q.db.QueryRowContext(ctx, updateTranscriptionMessageDeleted, arg.MessageID, arg.GuildID, arg.ChannelID)
+	var i AsrTranscription
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.OriginalMessageID,
+		&i.OriginalMessageDeleted,
+		&i.OriginalMessageTimestamp,
+		&i.ResponseMessageID,
+		&i.ResponseDeleted,
+		&i.TranscriptionStatus,
+		&i.VoiceMessageAudioDuration,
+		&i.TranscriptionModel,
+		&i.TranscriptionProcessingTime,
+		&i.AuthorID,
+		&i.RequesterID,
+	)
+	return i, err
+}
+
+const updateUserASREnabled = `-- name: UpdateUserASREnabled :exec
+UPDATE users
+SET asr_enabled=?1, asr_enabled_touched_at=?2
+WHERE id=?3
+`
+
+type UpdateUserASREnabledParams struct {
+	AsrEnabled bool
+	Now        sql.NullInt64
+	ID         string
+}
+
+func (q *Queries) UpdateUserASREnabled(ctx context.Context, arg UpdateUserASREnabledParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserASREnabled, arg.AsrEnabled, arg.Now, arg.ID)
+	return err
+}
+
+const updateUserASRNudge = `-- name: UpdateUserASRNudge :exec
+UPDATE users
+SET asr_nudged=?1, asr_nudged_touched_at=?2
+WHERE id=?3
+`
+
+type UpdateUserASRNudgeParams struct {
+	AsrNudged bool
+	Now       sql.NullInt64
+	ID        string
+}
+
+func (q *Queries) UpdateUserASRNudge(ctx context.Context, arg UpdateUserASRNudgeParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserASRNudge, arg.AsrNudged, arg.Now, arg.ID)
+	return err
+}
+
+const updateUserHistoryEnabled = `-- name: UpdateUserHistoryEnabled :exec
+UPDATE users
+SET history_enabled=?1, history_enabled_touched_at=?2
+WHERE id=?3
+`
+
+type UpdateUserHistoryEnabledParams struct {
+	HistoryEnabled bool
+	Now            sql.NullInt64
+	ID             string
+}
+
+func (q *Queries) UpdateUserHistoryEnabled(ctx context.Context, arg UpdateUserHistoryEnabledParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserHistoryEnabled, arg.HistoryEnabled, arg.Now, arg.ID)
+	return err
+}
+
+const upsertGuildSettings = `-- name: UpsertGuildSettings :one
+INSERT INTO guild_settings (
+    guild_id,
+    user_daily_audio_seconds,
+    guild_daily_audio_seconds,
+    low_confidence_action,
+    updated_by,
+    updated_at
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (guild_id) DO UPDATE
+SET
+    user_daily_audio_seconds=excluded.user_daily_audio_seconds,
+    guild_daily_audio_seconds=excluded.guild_daily_audio_seconds,
+    low_confidence_action=excluded.low_confidence_action,
+    updated_by=excluded.updated_by,
+    updated_at=excluded.updated_at
+RETURNING guild_id, user_daily_audio_seconds, guild_daily_audio_seconds, updated_at, updated_by, low_confidence_action
+`
+
+type UpsertGuildSettingsParams struct {
+	GuildID                string
+	UserDailyAudioSeconds  sql.NullFloat64
+	GuildDailyAudioSeconds sql.NullFloat64
+	LowConfidenceAction    string
+	UpdatedBy              sql.NullString
+	UpdatedAt              int64
+}
+
+func (q *Queries) UpsertGuildSettings(ctx context.Context, arg UpsertGuildSettingsParams) (GuildSetting, error) {
+	row := q.db.QueryRowContext(ctx, upsertGuildSettings,
+		arg.GuildID,
+		arg.UserDailyAudioSeconds,
+		arg.GuildDailyAudioSeconds,
+		arg.LowConfidenceAction,
+		arg.UpdatedBy,
+		arg.UpdatedAt,
+	)
+	var i GuildSetting
+	err := row.Scan(
+		&i.GuildID,
+		&i.UserDailyAudioSeconds,
+		&i.GuildDailyAudioSeconds,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.LowConfidenceAction,
+	)
+	return i, err
+}
@@ -0,0 +1,4 @@
+DROP TABLE guild_settings;
+DROP TABLE asr_transcript_texts;
+DROP TABLE asr_transcriptions;
+DROP TABLE users;
@@ -0,0 +1,92 @@
+-- The SQLite schema matches the Postgres one after all its migrations.
+-- Timestamps are unix microseconds, transcripts are encrypted by the program
+-- since there's no pgcrypto.
+
+CREATE TABLE users
+(
+    id TEXT NOT NULL,
+
+    asr_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    asr_enabled_touched_at INTEGER,
+    asr_nudged BOOLEAN NOT NULL DEFAULT FALSE,
+    asr_nudged_touched_at INTEGER,
+    history_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    history_enabled_touched_at INTEGER,
+
+    PRIMARY KEY(id)
+);
+
+CREATE TABLE asr_transcriptions
+(
+    guild_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+
+    original_message_id TEXT NOT NULL,
+    original_message_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    original_message_timestamp INTEGER NOT NULL,
+
+    response_message_id TEXT NOT NULL,
+    response_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+
+    transcription_status TEXT CHECK (transcription_status IN ('started', 'done', 'failed')),
+    voice_message_audio_duration REAL,
+    transcription_model TEXT,
+    transcription_processing_time REAL,
+
+    author_id TEXT,
+    requester_id TEXT,
+
+    PRIMARY KEY(guild_id, channel_id, original_message_id)
+);
+
+CREATE INDEX idx_asr_transcriptions_response_message
+ON asr_transcriptions (guild_id, channel_id, response_message_id);
+
+CREATE INDEX idx_asr_transcriptions_author
+ON asr_transcriptions (author_id, original_message_timestamp);
+
+CREATE INDEX idx_asr_transcriptions_requester
+ON asr_transcriptions (requester_id, original_message_timestamp);
+
+CREATE INDEX idx_asr_transcriptions_guild_timestamp
+ON asr_transcriptions (guild_id, original_message_timestamp);
+
+CREATE INDEX idx_asr_transcriptions_timestamp
+ON asr_transcriptions (original_message_timestamp);
+
+CREATE TABLE asr_transcript_texts
+(
+    guild_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+    original_message_id TEXT NOT NULL,
+
+    user_id TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+
+    -- AES-GCM with a key derived from the one in config
+    text_encrypted BLOB NOT NULL,
+
+    PRIMARY KEY(guild_id, channel_id, original_message_id),
+    FOREIGN KEY(guild_id, channel_id, original_message_id)
+        REFERENCES asr_transcriptions (guild_id, channel_id, original_message_id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_asr_transcript_texts_user
+ON asr_transcript_texts (user_id, created_at DESC);
+
+CREATE TABLE guild_settings
+(
+    guild_id TEXT NOT NULL,
+
+    -- NULL uses the deployment's default
+    user_daily_audio_seconds REAL,
+    guild_daily_audio_seconds REAL,
+
+    updated_at INTEGER NOT NULL,
+    updated_by TEXT,
+
+    low_confidence_action TEXT NOT NULL DEFAULT 'flag' CHECK (low_confidence_action IN ('flag', 'suppress')),
+
+    PRIMARY KEY(guild_id)
+);
@@ -0,0 +1 @@
+DROP TABLE asr_transcription_daily_stats;
@@ -0,0 +1,14 @@
+-- Aggregates of transcriptions pruned by the retention job, days are
+-- YYYY-MM-DD in UTC.
+CREATE TABLE asr_transcription_daily_stats
+(
+    day TEXT NOT NULL,
+    guild_id TEXT NOT NULL,
+    transcription_model TEXT NOT NULL,
+
+    transcriptions INTEGER NOT NULL,
+    failed INTEGER NOT NULL,
+    audio_duration REAL NOT NULL,
+
+    PRIMARY KEY(day, guild_id, transcription_model)
+);
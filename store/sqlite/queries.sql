@@ -0,0 +1,210 @@
+-- name: CreateUser :exec
+INSERT INTO users (id)
+VALUES (?)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id=? LIMIT 1;
+
+-- name: UpdateUserASREnabled :exec
+UPDATE users
+SET asr_enabled=sqlc.arg(asr_enabled), asr_enabled_touched_at=sqlc.arg(now)
+WHERE id=sqlc.arg(id);
+
+-- name: UpdateUserASRNudge :exec
+UPDATE users
+SET asr_nudged=sqlc.arg(asr_nudged), asr_nudged_touched_at=sqlc.arg(now)
+WHERE id=sqlc.arg(id);
+
+-- name: UpdateUserHistoryEnabled :exec
+UPDATE users
+SET history_enabled=sqlc.arg(history_enabled), history_enabled_touched_at=sqlc.arg(now)
+WHERE id=sqlc.arg(id);
+
+-- name: CreateStartedTranscription :exec
+INSERT INTO asr_transcriptions (
+    guild_id,
+    channel_id,
+    original_message_id,
+    original_message_deleted,
+    original_message_timestamp,
+    response_message_id,
+    author_id,
+    requester_id,
+    transcription_status
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'started');
+
+-- name: UpdateTranscriptionDone :one
+UPDATE asr_transcriptions
+SET
+    transcription_status='done',
+    voice_message_audio_duration=sqlc.arg(voice_message_audio_duration),
+    transcription_model=sqlc.arg(transcription_model),
+    transcription_processing_time=sqlc.arg(transcription_processing_time)
+WHERE
+    guild_id=sqlc.arg(guild_id) AND
+    channel_id=sqlc.arg(channel_id) AND
+    original_message_id=sqlc.arg(original_message_id)
+RETURNING *;
+
+-- name: UpdateTranscriptionFailed :one
+UPDATE asr_transcriptions
+SET
+    transcription_status='failed'
+WHERE
+    guild_id=sqlc.arg(guild_id) AND
+    channel_id=sqlc.arg(channel_id) AND
+    original_message_id=sqlc.arg(original_message_id)
+RETURNING *;
+
+-- name: UpdateTranscriptionMessageDeleted :one
+UPDATE asr_transcriptions
+SET
+    response_deleted=
+        CASE WHEN response_message_id=sqlc.arg(message_id) THEN TRUE ELSE response_deleted END,
+
+    original_message_deleted=
+        CASE WHEN original_message_id=sqlc.arg(message_id) THEN TRUE ELSE original_message_deleted END
+WHERE
+    guild_id=sqlc.arg(guild_id) AND
+    channel_id=sqlc.arg(channel_id) AND
+    (
+        original_message_id=sqlc.arg(message_id) OR
+        response_message_id=sqlc.arg(message_id)
+    )
+RETURNING *;
+
+-- name: CreateTranscriptText :exec
+INSERT INTO asr_transcript_texts (
+    guild_id,
+    channel_id,
+    original_message_id,
+    user_id,
+    created_at,
+    text_encrypted
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListTranscriptTexts :many
+SELECT * FROM asr_transcript_texts
+WHERE user_id=sqlc.arg(user_id)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListAllTranscriptTexts :many
+SELECT * FROM asr_transcript_texts
+WHERE user_id=sqlc.arg(user_id)
+ORDER BY created_at;
+
+-- name: ListTranscriptionsByAuthor :many
+SELECT * FROM asr_transcriptions
+WHERE author_id=CAST(sqlc.arg(author_id) AS TEXT)
+ORDER BY original_message_timestamp;
+
+-- name: ListTranscriptionsSince :many
+SELECT * FROM asr_transcriptions
+WHERE
+    (CAST(sqlc.narg(guild_id) AS TEXT) IS NULL OR guild_id=CAST(sqlc.narg(guild_id) AS TEXT)) AND
+    original_message_timestamp >= sqlc.arg(since);
+
+-- name: GetRequesterTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    CAST(COALESCE(SUM(transcription_status='done'), 0) AS INTEGER) AS transcriptions_done,
+    CAST(COALESCE(SUM(voice_message_audio_duration), 0) AS REAL) AS audio_duration
+FROM asr_transcriptions
+WHERE
+    requester_id=CAST(sqlc.arg(requester_id) AS TEXT) AND
+    original_message_timestamp >= sqlc.arg(since);
+
+-- name: GetGuildTranscriptionTotals :one
+SELECT
+    COUNT(*) AS transcriptions,
+    CAST(COALESCE(SUM(transcription_status='done'), 0) AS INTEGER) AS transcriptions_done,
+    CAST(COALESCE(SUM(voice_message_audio_duration), 0) AS REAL) AS audio_duration
+FROM asr_transcriptions
+WHERE
+    guild_id=sqlc.arg(guild_id) AND
+    original_message_timestamp >= sqlc.arg(since);
+
+-- name: DeleteTranscriptTextsByUser :execrows
+DELETE FROM asr_transcript_texts
+WHERE user_id=?;
+
+-- name: DeleteTranscriptionsByAuthor :many
+DELETE FROM asr_transcriptions
+WHERE author_id=CAST(sqlc.arg(author_id) AS TEXT)
+RETURNING *;
+
+-- name: ClearTranscriptionsRequester :many
+UPDATE asr_transcriptions
+SET requester_id=NULL
+WHERE requester_id=CAST(sqlc.arg(requester_id) AS TEXT)
+RETURNING *;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id=?;
+
+-- name: GetGuildSettings :one
+SELECT * FROM guild_settings
+WHERE guild_id=? LIMIT 1;
+
+-- name: UpsertGuildSettings :one
+INSERT INTO guild_settings (
+    guild_id,
+    user_daily_audio_seconds,
+    guild_daily_audio_seconds,
+    low_confidence_action,
+    updated_by,
+    updated_at
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (guild_id) DO UPDATE
+SET
+    user_daily_audio_seconds=excluded.user_daily_audio_seconds,
+    guild_daily_audio_seconds=excluded.guild_daily_audio_seconds,
+    low_confidence_action=excluded.low_confidence_action,
+    updated_by=excluded.updated_by,
+    updated_at=excluded.updated_at
+RETURNING *;
+
+-- name: PruneTranscriptions :many
+DELETE FROM asr_transcriptions
+WHERE rowid IN (
+    SELECT pruned.rowid
+    FROM asr_transcriptions AS pruned
+    WHERE
+        pruned.original_message_timestamp < sqlc.arg(before) AND
+        -- keep transcriptions in someone's /history
+        NOT EXISTS (
+            SELECT 1 FROM asr_transcript_texts
+            WHERE
+                asr_transcript_texts.guild_id=pruned.guild_id AND
+                asr_transcript_texts.channel_id=pruned.channel_id AND
+                asr_transcript_texts.original_message_id=pruned.original_message_id
+        )
+    ORDER BY pruned.original_message_timestamp
+    LIMIT sqlc.arg(row_limit)
+)
+RETURNING *;
+
+-- name: AddTranscriptionDailyStats :exec
+INSERT INTO asr_transcription_daily_stats (
+    day,
+    guild_id,
+    transcription_model,
+    transcriptions,
+    failed,
+    audio_duration
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (day, guild_id, transcription_model) DO UPDATE
+SET
+    transcriptions=transcriptions+excluded.transcriptions,
+    failed=failed+excluded.failed,
+    audio_duration=audio_duration+excluded.audio_duration;
+
+-- name: ListTranscriptionDailyStats :many
+SELECT * FROM asr_transcription_daily_stats
+WHERE
+    (CAST(sqlc.narg(guild_id) AS TEXT) IS NULL OR guild_id=CAST(sqlc.narg(guild_id) AS TEXT)) AND
+    day >= CAST(sqlc.arg(since) AS TEXT);
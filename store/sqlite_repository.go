@@ -0,0 +1,355 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	sqlitedb "github.com/K3das/orange/store/sqlite/db"
+)
+
+func (s *sqliteStore) GetOrCreateUser(ctx context.Context, userID string) (*db.User, error) {
+	err := s.q.CreateUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("creating member: %w", err)
+	}
+
+	user, err := s.q.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting member: %w", err)
+	}
+
+	member := sqliteUser(user)
+	return &member, nil
+}
+
+func (s *sqliteStore) UpdateUserASREnabled(ctx context.Context, arg db.UpdateUserASREnabledParams) error {
+	return s.q.UpdateUserASREnabled(ctx, sqlitedb.UpdateUserASREnabledParams{
+		AsrEnabled: arg.AsrEnabled,
+		Now:        sql.NullInt64{Int64: sqliteNow(), Valid: true},
+		ID:         arg.ID,
+	})
+}
+
+func (s *sqliteStore) UpdateUserASRNudge(ctx context.Context, arg db.UpdateUserASRNudgeParams) error {
+	return s.q.UpdateUserASRNudge(ctx, sqlitedb.UpdateUserASRNudgeParams{
+		AsrNudged: arg.AsrNudged,
+		Now:       sql.NullInt64{Int64: sqliteNow(), Valid: true},
+		ID:        arg.ID,
+	})
+}
+
+func (s *sqliteStore) UpdateUserHistoryEnabled(ctx context.Context, arg db.UpdateUserHistoryEnabledParams) error {
+	return s.q.UpdateUserHistoryEnabled(ctx, sqlitedb.UpdateUserHistoryEnabledParams{
+		HistoryEnabled: arg.HistoryEnabled,
+		Now:            sql.NullInt64{Int64: sqliteNow(), Valid: true},
+		ID:             arg.ID,
+	})
+}
+
+func (s *sqliteStore) CreateStartedTranscription(ctx context.Context, arg db.CreateStartedTranscriptionParams) error {
+	return s.q.CreateStartedTranscription(ctx, sqlitedb.CreateStartedTranscriptionParams{
+		GuildID:                  arg.GuildID,
+		ChannelID:                arg.ChannelID,
+		OriginalMessageID:        arg.OriginalMessageID,
+		OriginalMessageDeleted:   arg.OriginalMessageDeleted,
+		OriginalMessageTimestamp: sqliteTime(arg.OriginalMessageTimestamp.Time),
+		ResponseMessageID:        arg.ResponseMessageID,
+		AuthorID:                 sqliteNullString(arg.AuthorID),
+		RequesterID:              sqliteNullString(arg.RequesterID),
+	})
+}
+
+func (s *sqliteStore) UpdateTranscriptionDone(ctx context.Context, arg db.UpdateTranscriptionDoneParams) (db.AsrTranscription, error) {
+	transcription, err := s.q.UpdateTranscriptionDone(ctx, sqlitedb.UpdateTranscriptionDoneParams{
+		VoiceMessageAudioDuration:   sqliteNullFloat64(arg.VoiceMessageAudioDuration),
+		TranscriptionModel:          sqliteNullString(arg.TranscriptionModel),
+		TranscriptionProcessingTime: sqliteNullFloat64(arg.TranscriptionProcessingTime),
+		GuildID:                     arg.GuildID,
+		ChannelID:                   arg.ChannelID,
+		OriginalMessageID:           arg.OriginalMessageID,
+	})
+	if err != nil {
+		return db.AsrTranscription{}, notFound(err)
+	}
+	return sqliteTranscription(transcription), nil
+}
+
+func (s *sqliteStore) UpdateTranscriptionFailed(ctx context.Context, arg db.UpdateTranscriptionFailedParams) (db.AsrTranscription, error) {
+	transcription, err := s.q.UpdateTranscriptionFailed(ctx, sqlitedb.UpdateTranscriptionFailedParams{
+		GuildID:           arg.GuildID,
+		ChannelID:         arg.ChannelID,
+		OriginalMessageID: arg.OriginalMessageID,
+	})
+	if err != nil {
+		return db.AsrTranscription{}, notFound(err)
+	}
+	return sqliteTranscription(transcription), nil
+}
+
+func (s *sqliteStore) UpdateTranscriptionMessageDeleted(ctx context.Context, arg db.UpdateTranscriptionMessageDeletedParams) (db.AsrTranscription, error) {
+	transcription, err := s.q.UpdateTranscriptionMessageDeleted(ctx, sqlitedb.UpdateTranscriptionMessageDeletedParams{
+		MessageID: arg.MessageID,
+		GuildID:   arg.GuildID,
+		ChannelID: arg.ChannelID,
+	})
+	if err != nil {
+		return db.AsrTranscription{}, notFound(err)
+	}
+	return sqliteTranscription(transcription), nil
+}
+
+func (s *sqliteStore) GetRequesterTranscriptionTotals(ctx context.Context, arg db.GetRequesterTranscriptionTotalsParams) (db.GetRequesterTranscriptionTotalsRow, error) {
+	totals, err := s.q.GetRequesterTranscriptionTotals(ctx, sqlitedb.GetRequesterTranscriptionTotalsParams{
+		RequesterID: arg.RequesterID,
+		Since:       sqliteTime(arg.Since.Time),
+	})
+	return db.GetRequesterTranscriptionTotalsRow(totals), err
+}
+
+func (s *sqliteStore) GetGuildTranscriptionTotals(ctx context.Context, arg db.GetGuildTranscriptionTotalsParams) (db.GetGuildTranscriptionTotalsRow, error) {
+	totals, err := s.q.GetGuildTranscriptionTotals(ctx, sqlitedb.GetGuildTranscriptionTotalsParams{
+		GuildID: arg.GuildID,
+		Since:   sqliteTime(arg.Since.Time),
+	})
+	return db.GetGuildTranscriptionTotalsRow(totals), err
+}
+
+// GetUsageStats aggregates in Go, SQLite has no percentile_cont.
+func (s *sqliteStore) GetUsageStats(ctx context.Context, guildID string, since time.Time, pricesPerMinute map[string]float64) (*UsageStats, error) {
+	rows, err := s.q.ListTranscriptionsSince(ctx, sqlitedb.ListTranscriptionsSinceParams{
+		GuildID: sql.NullString{String: guildID, Valid: guildID != ""},
+		Since:   sqliteTime(since),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing transcriptions: %w", err)
+	}
+
+	dailyRows, err := s.q.ListTranscriptionDailyStats(ctx, sqlitedb.ListTranscriptionDailyStatsParams{
+		GuildID: sql.NullString{String: guildID, Valid: guildID != ""},
+		Since:   rollupSince(since).Format(time.DateOnly),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting pruned stats: %w", err)
+	}
+	daily := make([]dailyStats, 0, len(dailyRows))
+	for _, row := range dailyRows {
+		daily = append(daily, dailyStats{
+			day:            row.Day,
+			guildID:        row.GuildID,
+			model:          row.TranscriptionModel,
+			transcriptions: row.Transcriptions,
+			failed:         row.Failed,
+			audioDuration:  row.AudioDuration,
+		})
+	}
+
+	return aggregateUsageStats(sqliteTranscriptions(rows), daily, guildID, since, pricesPerMinute), nil
+}
+
+func (s *sqliteStore) GetGuildSettingsOrDefault(ctx context.Context, guildID string) (*db.GuildSetting, error) {
+	settings, err := s.q.GetGuildSettings(ctx, guildID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &db.GuildSetting{
+			GuildID:             guildID,
+			LowConfidenceAction: db.LowConfidenceActionFlag,
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting guild settings: %w", err)
+	}
+
+	guildSettings := sqliteGuildSetting(settings)
+	return &guildSettings, nil
+}
+
+func (s *sqliteStore) UpsertGuildSettings(ctx context.Context, arg db.UpsertGuildSettingsParams) (db.GuildSetting, error) {
+	settings, err := s.q.UpsertGuildSettings(ctx, sqlitedb.UpsertGuildSettingsParams{
+		GuildID:                arg.GuildID,
+		UserDailyAudioSeconds:  sqliteNullFloat64(arg.UserDailyAudioSeconds),
+		GuildDailyAudioSeconds: sqliteNullFloat64(arg.GuildDailyAudioSeconds),
+		LowConfidenceAction:    string(arg.LowConfidenceAction),
+		UpdatedBy:              sqliteNullString(arg.UpdatedBy),
+		UpdatedAt:              sqliteNow(),
+	})
+	if err != nil {
+		return db.GuildSetting{}, err
+	}
+	return sqliteGuildSetting(settings), nil
+}
+
+func (s *sqliteStore) HistoryAvailable() bool {
+	return s.transcriptCipher != nil
+}
+
+func (s *sqliteStore) StoreTranscriptText(ctx context.Context, transcription *db.AsrTranscription, userID string, text string) error {
+	if !s.HistoryAvailable() {
+		return ErrHistoryUnavailable
+	}
+
+	encrypted, err := s.encryptTranscript(text)
+	if err != nil {
+		return err
+	}
+
+	return s.q.CreateTranscriptText(ctx, sqlitedb.CreateTranscriptTextParams{
+		GuildID:           transcription.GuildID,
+		ChannelID:         transcription.ChannelID,
+		OriginalMessageID: transcription.OriginalMessageID,
+		UserID:            userID,
+		CreatedAt:         sqliteNow(),
+		TextEncrypted:     encrypted,
+	})
+}
+
+// GetTranscriptHistory searches in Go, since transcripts can only be read
+// after decrypting them.
+func (s *sqliteStore) GetTranscriptHistory(ctx context.Context, userID string, query string, limit, offset int32) ([]TranscriptHistoryEntry, error) {
+	if !s.HistoryAvailable() {
+		return nil, ErrHistoryUnavailable
+	}
+
+	if !searchQuery(query) {
+		rows, err := s.q.ListTranscriptTexts(ctx, sqlitedb.ListTranscriptTextsParams{
+			UserID:    userID,
+			RowLimit:  int64(limit),
+			RowOffset: int64(offset),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing transcripts: %w", err)
+		}
+		return s.decryptTranscripts(rows)
+	}
+
+	rows, err := s.q.ListAllTranscriptTexts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("searching transcripts: %w", err)
+	}
+	entries, err := s.decryptTranscripts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageHistory(entries, query, limit, offset), nil
+}
+
+func (s *sqliteStore) ExportUserData(ctx context.Context, userID string) (*UserDataExport, error) {
+	export := &UserDataExport{
+		ExportedAt:     time.Now().UTC(),
+		Transcriptions: []ExportedTranscription{},
+		Transcripts:    []ExportedTranscript{},
+	}
+
+	user, err := s.q.GetUser(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("getting user: %w", err)
+	} else if err == nil {
+		export.User = exportUser(sqliteUser(user))
+	}
+
+	transcriptions, err := s.q.ListTranscriptionsByAuthor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing transcriptions: %w", err)
+	}
+	for _, t := range transcriptions {
+		export.Transcriptions = append(export.Transcriptions, exportTranscription(sqliteTranscription(t)))
+	}
+
+	if s.HistoryAvailable() {
+		rows, err := s.q.ListAllTranscriptTexts(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("listing transcripts: %w", err)
+		}
+		transcripts, err := s.decryptTranscripts(rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range transcripts {
+			export.Transcripts = append(export.Transcripts, ExportedTranscript(t))
+		}
+	}
+
+	return export, nil
+}
+
+func (s *sqliteStore) DeleteUserData(ctx context.Context, userID string) (*UserDataDeletion, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.q.WithTx(tx)
+	deletion := &UserDataDeletion{}
+
+	deletion.TranscriptsDeleted, err = q.DeleteTranscriptTextsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting transcripts: %w", err)
+	}
+
+	transcriptions, err := q.DeleteTranscriptionsByAuthor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting transcriptions: %w", err)
+	}
+	deletion.Transcriptions = sqliteTranscriptions(transcriptions)
+
+	cleared, err := q.ClearTranscriptionsRequester(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("clearing requester: %w", err)
+	}
+	deletion.RequestsCleared = sqliteTranscriptions(cleared)
+
+	usersDeleted, err := q.DeleteUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting user: %w", err)
+	}
+	deletion.UserDeleted = usersDeleted > 0
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("committing: %w", err)
+	}
+
+	return deletion, nil
+}
+
+func (s *sqliteStore) PruneTranscriptions(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.q.WithTx(tx)
+
+	pruned, err := q.PruneTranscriptions(ctx, sqlitedb.PruneTranscriptionsParams{
+		Before:   sqliteTime(before),
+		RowLimit: int64(limit),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("deleting transcriptions: %w", err)
+	}
+
+	for _, daily := range rollUpTranscriptions(sqliteTranscriptions(pruned)) {
+		err = q.AddTranscriptionDailyStats(ctx, sqlitedb.AddTranscriptionDailyStatsParams{
+			Day:                daily.day,
+			GuildID:            daily.guildID,
+			TranscriptionModel: daily.model,
+			Transcriptions:     daily.transcriptions,
+			Failed:             daily.failed,
+			AudioDuration:      daily.audioDuration,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("adding daily stats: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("committing: %w", err)
+	}
+
+	return int64(len(pruned)), nil
+}
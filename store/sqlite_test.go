@@ -0,0 +1,56 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/storetest"
+	"go.uber.org/zap"
+)
+
+// connectTestStore connects a store to dsn, closing it when the test ends.
+func connectTestStore(t *testing.T, dsn string, options ...store.StoreOptions) *store.Store {
+	t.Helper()
+
+	s := store.NewStore(context.Background(), zap.NewNop(), options...)
+	if err := s.Connect(context.Background(), dsn); err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestSQLiteStore(t *testing.T) {
+	t.Run("without_history", func(t *testing.T) {
+		dsn := "sqlite://" + filepath.Join(t.TempDir(), "orange.db")
+		storetest.TestRepository(t, connectTestStore(t, dsn))
+	})
+	t.Run("with_history", func(t *testing.T) {
+		dsn := "sqlite://" + filepath.Join(t.TempDir(), "orange.db")
+		storetest.TestRepository(t, connectTestStore(t, dsn, store.WithTranscriptKey("storetest")))
+	})
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "orange.db")
+	connectTestStore(t, dsn)
+
+	// an up to date schema is only checked
+	s := connectTestStore(t, dsn, store.WithAutoMigrate(false))
+	migrator, err := s.Migrator()
+	if err != nil {
+		t.Fatalf("creating migrator: %v", err)
+	}
+	defer migrator.Close()
+
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("getting status: %v", err)
+	}
+	if status.Dirty || status.Version != status.Latest {
+		t.Errorf("status after migrating is %+v", status)
+	}
+}
@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StatsGuildLimit is how many of the busiest guilds bot-wide stats include.
+const StatsGuildLimit = 10
+
+type UsageStatsRow struct {
+	// Key is the model name, day (YYYY-MM-DD) or guild ID depending on the
+	// grouping, empty for totals
+	Key string
+
+	Transcriptions int64
+	Failed         int64
+	AudioDuration  float64
+	// ProcessingTimeP50 and ProcessingTimeP95 only cover transcriptions that
+	// haven't been pruned by the retention job
+	ProcessingTimeP50 float64
+	ProcessingTimeP95 float64
+
+	// EstimatedCost is only calculated for totals and models
+	EstimatedCost float64
+}
+
+type UsageStats struct {
+	Since time.Time
+
+	Total   UsageStatsRow
+	ByModel []UsageStatsRow
+	ByDay   []UsageStatsRow
+	// ByGuild is only set for bot-wide stats
+	ByGuild []UsageStatsRow
+}
+
+// GetUsageStats aggregates transcriptions since the given time for a guild, or
+// bot-wide if guildID is empty. Costs are estimated with pricesPerMinute,
+// keyed by model name; models without a price cost nothing.
+// Pruned transcriptions only have daily totals, so they're counted for whole
+// days after since.
+func (s *postgresStore) GetUsageStats(ctx context.Context, guildID string, since time.Time, pricesPerMinute map[string]float64) (*UsageStats, error) {
+	guildArg := pgtype.Text{
+		String: guildID,
+		Valid:  guildID != "",
+	}
+	sinceArg := pgtype.Timestamptz{
+		Time:  since,
+		Valid: true,
+	}
+
+	stats := &UsageStats{
+		Since: since,
+	}
+
+	total, err := s.GetTranscriptionStatsTotal(ctx, db.GetTranscriptionStatsTotalParams{
+		GuildID: guildArg,
+		Since:   sinceArg,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting totals: %w", err)
+	}
+	stats.Total = UsageStatsRow{
+		Transcriptions:    total.Transcriptions,
+		Failed:            total.Failed,
+		AudioDuration:     total.AudioDuration,
+		ProcessingTimeP50: total.ProcessingTimeP50,
+		ProcessingTimeP95: total.ProcessingTimeP95,
+	}
+
+	byModel, err := s.GetTranscriptionStatsByModel(ctx, db.GetTranscriptionStatsByModelParams{
+		GuildID: guildArg,
+		Since:   sinceArg,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting stats by model: %w", err)
+	}
+	for _, row := range byModel {
+		stats.ByModel = append(stats.ByModel, UsageStatsRow{
+			Key:               row.TranscriptionModel,
+			Transcriptions:    row.Transcriptions,
+			Failed:            row.Failed,
+			AudioDuration:     row.AudioDuration,
+			ProcessingTimeP50: row.ProcessingTimeP50,
+			ProcessingTimeP95: row.ProcessingTimeP95,
+		})
+	}
+
+	byDay, err := s.GetTranscriptionStatsByDay(ctx, db.GetTranscriptionStatsByDayParams{
+		GuildID: guildArg,
+		Since:   sinceArg,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting stats by day: %w", err)
+	}
+	for _, row := range byDay {
+		stats.ByDay = append(stats.ByDay, UsageStatsRow{
+			Key:               row.Day.Time.UTC().Format(time.DateOnly),
+			Transcriptions:    row.Transcriptions,
+			Failed:            row.Failed,
+			AudioDuration:     row.AudioDuration,
+			ProcessingTimeP50: row.ProcessingTimeP50,
+			ProcessingTimeP95: row.ProcessingTimeP95,
+		})
+	}
+
+	if guildID == "" {
+		byGuild, err := s.GetTranscriptionStatsByGuild(ctx, db.GetTranscriptionStatsByGuildParams{
+			GuildID: guildArg,
+			Since:   sinceArg,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting stats by guild: %w", err)
+		}
+		for _, row := range byGuild {
+			stats.ByGuild = append(stats.ByGuild, UsageStatsRow{
+				Key:               row.GuildID,
+				Transcriptions:    row.Transcriptions,
+				Failed:            row.Failed,
+				AudioDuration:     row.AudioDuration,
+				ProcessingTimeP50: row.ProcessingTimeP50,
+				ProcessingTimeP95: row.ProcessingTimeP95,
+			})
+		}
+	}
+
+	dailyRows, err := s.ListTranscriptionDailyStats(ctx, db.ListTranscriptionDailyStatsParams{
+		GuildID: guildArg,
+		Since: pgtype.Date{
+			Time:  rollupSince(since),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting pruned stats: %w", err)
+	}
+	daily := make([]dailyStats, 0, len(dailyRows))
+	for _, row := range dailyRows {
+		daily = append(daily, dailyStats{
+			day:            row.Day.Time.Format(time.DateOnly),
+			guildID:        row.GuildID,
+			model:          row.TranscriptionModel,
+			transcriptions: row.Transcriptions,
+			failed:         row.Failed,
+			audioDuration:  row.AudioDuration,
+		})
+	}
+	stats.addDailyStats(daily, guildID)
+
+	stats.estimateCosts(pricesPerMinute)
+
+	return stats, nil
+}
+
+// addDailyStats merges the daily stats of pruned transcriptions into the
+// stats, and limits ByGuild to the busiest guilds. Pruned transcriptions
+// don't count towards the processing time percentiles.
+func (stats *UsageStats) addDailyStats(daily []dailyStats, guildID string) {
+	add := func(rows []UsageStatsRow, key string, d dailyStats) []UsageStatsRow {
+		i := slices.IndexFunc(rows, func(row UsageStatsRow) bool {
+			return row.Key == key
+		})
+		if i < 0 {
+			rows = append(rows, UsageStatsRow{Key: key})
+			i = len(rows) - 1
+		}
+		rows[i].Transcriptions += d.transcriptions
+		rows[i].Failed += d.failed
+		rows[i].AudioDuration += d.audioDuration
+		return rows
+	}
+
+	for _, d := range daily {
+		stats.Total.Transcriptions += d.transcriptions
+		stats.Total.Failed += d.failed
+		stats.Total.AudioDuration += d.audioDuration
+
+		stats.ByModel = add(stats.ByModel, d.model, d)
+		stats.ByDay = add(stats.ByDay, d.day, d)
+		if guildID == "" {
+			stats.ByGuild = add(stats.ByGuild, d.guildID, d)
+		}
+	}
+
+	slices.SortStableFunc(stats.ByModel, byTranscriptionsDesc)
+	slices.SortStableFunc(stats.ByDay, func(a, b UsageStatsRow) int {
+		return strings.Compare(b.Key, a.Key)
+	})
+	slices.SortStableFunc(stats.ByGuild, byTranscriptionsDesc)
+	if len(stats.ByGuild) > StatsGuildLimit {
+		stats.ByGuild = stats.ByGuild[:StatsGuildLimit]
+	}
+}
+
+// statsDay is the UTC day of t, which stats are grouped by.
+func statsDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// rollupSince is the first day whose daily stats are entirely from since on.
+// The rollup for the day since falls in may have transcriptions from before it.
+func rollupSince(since time.Time) time.Time {
+	day := statsDay(since)
+	if day.Before(since) {
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// estimateCosts prices each model's audio and sums them into the total.
+func (stats *UsageStats) estimateCosts(pricesPerMinute map[string]float64) {
+	for i, row := range stats.ByModel {
+		cost := row.AudioDuration / 60 * pricesPerMinute[row.Key]
+		stats.ByModel[i].EstimatedCost = cost
+		stats.Total.EstimatedCost += cost
+	}
+}
+
+// statsGroup accumulates a UsageStatsRow.
+type statsGroup struct {
+	row             UsageStatsRow
+	processingTimes []float64
+}
+
+func (g *statsGroup) add(transcription db.AsrTranscription) {
+	g.row.Transcriptions++
+	if transcription.TranscriptionStatus.TranscriptionStatus == db.TranscriptionStatusFailed {
+		g.row.Failed++
+	}
+	g.row.AudioDuration += transcription.VoiceMessageAudioDuration.Float64
+	if transcription.TranscriptionProcessingTime.Valid {
+		g.processingTimes = append(g.processingTimes, transcription.TranscriptionProcessingTime.Float64)
+	}
+}
+
+func (g *statsGroup) finish() UsageStatsRow {
+	slices.Sort(g.processingTimes)
+	g.row.ProcessingTimeP50 = percentileCont(g.processingTimes, 0.5)
+	g.row.ProcessingTimeP95 = percentileCont(g.processingTimes, 0.95)
+	return g.row
+}
+
+// percentileCont interpolates a percentile of sorted values like Postgres'
+// percentile_cont, returning 0 without values.
+func percentileCont(sorted []float64, fraction float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := fraction * float64(len(sorted)-1)
+	lower := math.Floor(position)
+	upper := math.Ceil(position)
+	return sorted[int(lower)] + (sorted[int(upper)]-sorted[int(lower)])*(position-lower)
+}
+
+// groupStats groups transcriptions by key and sorts the rows with compare,
+// like GROUP BY and ORDER BY.
+func groupStats(transcriptions []db.AsrTranscription, key func(transcription db.AsrTranscription) string, compare func(a, b UsageStatsRow) int) []UsageStatsRow {
+	groups := map[string]*statsGroup{}
+	for _, transcription := range transcriptions {
+		k := key(transcription)
+		group, ok := groups[k]
+		if !ok {
+			group = &statsGroup{row: UsageStatsRow{Key: k}}
+			groups[k] = group
+		}
+		group.add(transcription)
+	}
+
+	var rows []UsageStatsRow
+	for _, group := range groups {
+		rows = append(rows, group.finish())
+	}
+	slices.SortFunc(rows, compare)
+
+	return rows
+}
+
+func byTranscriptionsDesc(a, b UsageStatsRow) int {
+	if a.Transcriptions != b.Transcriptions {
+		if a.Transcriptions > b.Transcriptions {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.Key, b.Key)
+}
+
+// aggregateUsageStats computes GetUsageStats from the matching transcriptions
+// and daily stats of pruned ones, for backends without percentile_cont.
+func aggregateUsageStats(transcriptions []db.AsrTranscription, daily []dailyStats, guildID string, since time.Time, pricesPerMinute map[string]float64) *UsageStats {
+	stats := &UsageStats{
+		Since: since,
+	}
+
+	total := &statsGroup{}
+	for _, transcription := range transcriptions {
+		total.add(transcription)
+	}
+	stats.Total = total.finish()
+
+	stats.ByModel = groupStats(transcriptions, func(transcription db.AsrTranscription) string {
+		return transcription.TranscriptionModel.String
+	}, byTranscriptionsDesc)
+
+	stats.ByDay = groupStats(transcriptions, func(transcription db.AsrTranscription) string {
+		return transcription.OriginalMessageTimestamp.Time.UTC().Format(time.DateOnly)
+	}, func(a, b UsageStatsRow) int {
+		return strings.Compare(b.Key, a.Key)
+	})
+
+	if guildID == "" {
+		stats.ByGuild = groupStats(transcriptions, func(transcription db.AsrTranscription) string {
+			return transcription.GuildID
+		}, byTranscriptionsDesc)
+	}
+	stats.addDailyStats(daily, guildID)
+
+	stats.estimateCosts(pricesPerMinute)
+
+	return stats
+}
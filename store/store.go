@@ -5,70 +5,155 @@ import (
 	"embed"
 	"fmt"
 
-	"github.com/K3das/orange/store/db"
-	"github.com/golang-migrate/migrate/v4"
-	migratePgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
-//go:embed migrations/*.sql
+//go:embed migrations/*.sql sqlite/migrations/*.sql
 var migrations embed.FS
 
+// backend is a database the store can connect to.
+type backend interface {
+	Repository
+
+	Ping(ctx context.Context) error
+	Close()
+
+	// migrationsDir is the backend's directory in migrations
+	migrationsDir() string
+	// migrateDriver returns a named golang-migrate driver for the database.
+	// Closing the driver mustn't close the backend.
+	migrateDriver() (string, database.Driver, error)
+	collector() prometheus.Collector
+}
+
+// Store is the Repository the bot uses, backed by Postgres or SQLite
+// depending on what it connects to.
 type Store struct {
 	log *zap.Logger
 
-	conn *pgxpool.Pool
+	// transcriptKey encrypts stored transcript texts, history is unavailable
+	// if it's empty
+	transcriptKey string
+
+	// autoMigrate applies migrations on connect, otherwise they're only
+	// checked
+	autoMigrate bool
+	// skipMigrations is for the migrate subcommand, which manages them itself
+	skipMigrations bool
 
-	*db.Queries
+	backend
+}
+
+type StoreOptions func(*Store)
+
+func WithTranscriptKey(key string) StoreOptions {
+	return func(s *Store) {
+		s.transcriptKey = key
+	}
 }
 
-func NewStore(ctx context.Context, parentLogger *zap.Logger) *Store {
-	s := &Store{}
+// WithAutoMigrate sets whether Connect applies migrations, defaults to true.
+// Deployments with several replicas can disable it and migrate once with the
+// migrate subcommand.
+func WithAutoMigrate(autoMigrate bool) StoreOptions {
+	return func(s *Store) {
+		s.autoMigrate = autoMigrate
+	}
+}
+
+// WithoutMigrations makes Connect leave migrations alone entirely, for
+// managing them with a Migrator.
+func WithoutMigrations() StoreOptions {
+	return func(s *Store) {
+		s.skipMigrations = true
+	}
+}
+
+func NewStore(ctx context.Context, parentLogger *zap.Logger, options ...StoreOptions) *Store {
+	s := &Store{
+		autoMigrate: true,
+	}
 	s.log = parentLogger.Named("store")
 
+	for _, option := range options {
+		option(s)
+	}
+
 	return s
 }
 
+// Connect opens the database, picking the backend by the DSN's scheme:
+// sqlite: for a SQLite file and anything else for Postgres.
 func (s *Store) Connect(ctx context.Context, dsn string) error {
-	pool, err := pgxpool.New(ctx, dsn)
+	var err error
+	if path, ok := sqliteDSNPath(dsn); ok {
+		s.backend, err = connectSQLite(ctx, path, s.transcriptKey)
+	} else {
+		s.backend, err = connectPostgres(ctx, dsn, s.transcriptKey)
+	}
 	if err != nil {
-		return fmt.Errorf("opening postgres: %w", err)
+		return err
+	}
+
+	if s.skipMigrations {
+		return nil
 	}
 
-	mFS, err := iofs.New(migrations, "migrations")
+	err = s.migrate()
 	if err != nil {
-		return fmt.Errorf("creating iofs driver: %w", err)
+		s.backend.Close()
+		return err
 	}
 
-	stdDB := stdlib.OpenDBFromPool(pool)
-	defer stdDB.Close()
+	return nil
+}
 
-	mDriver, err := migratePgx.WithInstance(stdDB, &migratePgx.Config{})
+// migrate brings the schema up to date if auto migration is enabled, and
+// otherwise checks it.
+func (s *Store) migrate() error {
+	migrator, err := s.Migrator()
 	if err != nil {
-		return fmt.Errorf("migrate driver instance: %w", err)
+		return err
+	}
+	defer migrator.Close()
+
+	if s.autoMigrate {
+		return migrator.Up(0)
 	}
 
-	m, err := migrate.NewWithInstance("iofs", mFS, "sqlite3", mDriver)
+	status, err := migrator.Status()
 	if err != nil {
-		return fmt.Errorf("migrate instance: %w", err)
+		return fmt.Errorf("getting migration status: %w", err)
 	}
-	if err := m.Up(); err == migrate.ErrNoChange {
-		s.log.Info("migrations done (no change)")
-	} else if err != nil {
-		return fmt.Errorf("running migrations: %w", err)
-	} else {
-		s.log.Info("migrations done")
+	if status.Dirty {
+		return fmt.Errorf("schema version %d is dirty, fix it and run migrate force", status.Version)
+	}
+	if status.Version != status.Latest {
+		s.log.With(
+			zap.Uint("version", status.Version),
+			zap.Uint("latest", status.Latest),
+		).Warn("schema isn't at the latest version, run migrate up")
 	}
-
-	s.Queries = db.New(pool)
-	s.conn = pool
 
 	return nil
 }
 
 func (s *Store) Close() {
-	s.conn.Close()
+	s.backend.Close()
+}
+
+// Ping checks that the database is reachable.
+func (s *Store) Ping(ctx context.Context) error {
+	if s.backend == nil {
+		return fmt.Errorf("not connected")
+	}
+	return s.backend.Ping(ctx)
+}
+
+// Collector returns a prometheus collector for the database's connections.
+// The store must be connected.
+func (s *Store) Collector() prometheus.Collector {
+	return s.backend.collector()
 }
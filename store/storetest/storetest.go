@@ -0,0 +1,817 @@
+// Package storetest checks that store.Repository implementations behave like
+// the Postgres store.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/K3das/orange/store"
+	"github.com/K3das/orange/store/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestRepository runs the conformance checks against repo, each as a
+// subtest of t. Rows are created with random IDs, so it can run against a
+// database that's in use; guild-wide stats only cover the guilds it creates.
+// Retention is checked by pruning transcriptions from before 2000-01-03.
+func TestRepository(t *testing.T, repo store.Repository) {
+	c := &checker{
+		repo:   repo,
+		prefix: fmt.Sprintf("storetest-%d-", rand.Uint64()),
+		now:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	checks := []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{"users", c.testUsers},
+		{"transcriptions", c.testTranscriptions},
+		{"message_deletion", c.testMessageDeleted},
+		{"totals", c.testTotals},
+		{"usage_stats", c.testUsageStats},
+		{"guild_settings", c.testGuildSettings},
+		{"history", c.testHistory},
+		{"privacy", c.testPrivacy},
+		{"retention", c.testRetention},
+	}
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			if err := check.run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type checker struct {
+	repo   store.Repository
+	prefix string
+	now    time.Time
+}
+
+func (c *checker) id(name string) string {
+	return c.prefix + name
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: true}
+}
+
+func float8(f float64) pgtype.Float8 {
+	return pgtype.Float8{Float64: f, Valid: true}
+}
+
+// transcription creates a started transcription of message in guild, authored
+// and requested by userID.
+func (c *checker) transcription(ctx context.Context, guild, message, userID string, timestamp time.Time) (db.CreateStartedTranscriptionParams, error) {
+	arg := db.CreateStartedTranscriptionParams{
+		GuildID:                  c.id(guild),
+		ChannelID:                c.id("channel"),
+		OriginalMessageID:        c.id(message),
+		OriginalMessageTimestamp: timestamptz(timestamp),
+		ResponseMessageID:        c.id(message + "-response"),
+		AuthorID:                 text(userID),
+		RequesterID:              text(userID),
+	}
+	err := c.repo.CreateStartedTranscription(ctx, arg)
+	if err != nil {
+		return arg, fmt.Errorf("creating transcription %s: %w", message, err)
+	}
+	return arg, nil
+}
+
+func (c *checker) done(ctx context.Context, arg db.CreateStartedTranscriptionParams, model string, duration, processingTime float64) (db.AsrTranscription, error) {
+	transcription, err := c.repo.UpdateTranscriptionDone(ctx, db.UpdateTranscriptionDoneParams{
+		GuildID:                     arg.GuildID,
+		ChannelID:                   arg.ChannelID,
+		OriginalMessageID:           arg.OriginalMessageID,
+		VoiceMessageAudioDuration:   float8(duration),
+		TranscriptionModel:          text(model),
+		TranscriptionProcessingTime: float8(processingTime),
+	})
+	if err != nil {
+		return transcription, fmt.Errorf("marking %s done: %w", arg.OriginalMessageID, err)
+	}
+	return transcription, nil
+}
+
+func (c *checker) failed(ctx context.Context, arg db.CreateStartedTranscriptionParams) error {
+	_, err := c.repo.UpdateTranscriptionFailed(ctx, db.UpdateTranscriptionFailedParams{
+		GuildID:           arg.GuildID,
+		ChannelID:         arg.ChannelID,
+		OriginalMessageID: arg.OriginalMessageID,
+	})
+	if err != nil {
+		return fmt.Errorf("marking %s failed: %w", arg.OriginalMessageID, err)
+	}
+	return nil
+}
+
+func (c *checker) testUsers(ctx context.Context) error {
+	userID := c.id("user")
+
+	user, err := c.repo.GetOrCreateUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	if user.ID != userID || user.AsrEnabled || user.AsrNudged || user.HistoryEnabled {
+		return fmt.Errorf("new user doesn't have defaults: %+v", user)
+	}
+	if user.AsrEnabledTouchedAt.Valid || user.AsrNudgedTouchedAt.Valid || user.HistoryEnabledTouchedAt.Valid {
+		return fmt.Errorf("new user has touched timestamps: %+v", user)
+	}
+
+	err = c.repo.UpdateUserASREnabled(ctx, db.UpdateUserASREnabledParams{ID: userID, AsrEnabled: true})
+	if err != nil {
+		return fmt.Errorf("enabling asr: %w", err)
+	}
+	err = c.repo.UpdateUserASRNudge(ctx, db.UpdateUserASRNudgeParams{ID: userID, AsrNudged: true})
+	if err != nil {
+		return fmt.Errorf("nudging: %w", err)
+	}
+	err = c.repo.UpdateUserHistoryEnabled(ctx, db.UpdateUserHistoryEnabledParams{ID: userID, HistoryEnabled: true})
+	if err != nil {
+		return fmt.Errorf("enabling history: %w", err)
+	}
+
+	user, err = c.repo.GetOrCreateUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("getting user: %w", err)
+	}
+	if !user.AsrEnabled || !user.AsrNudged || !user.HistoryEnabled {
+		return fmt.Errorf("updates weren't saved: %+v", user)
+	}
+	if !user.AsrEnabledTouchedAt.Valid || !user.AsrNudgedTouchedAt.Valid || !user.HistoryEnabledTouchedAt.Valid {
+		return fmt.Errorf("updates didn't set touched timestamps: %+v", user)
+	}
+
+	// updating a user that doesn't exist does nothing
+	missingID := c.id("missing-user")
+	err = c.repo.UpdateUserASREnabled(ctx, db.UpdateUserASREnabledParams{ID: missingID, AsrEnabled: true})
+	if err != nil {
+		return fmt.Errorf("updating missing user: %w", err)
+	}
+	user, err = c.repo.GetOrCreateUser(ctx, missingID)
+	if err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	if user.AsrEnabled {
+		return fmt.Errorf("updating a missing user created it")
+	}
+
+	return nil
+}
+
+func (c *checker) testTranscriptions(ctx context.Context) error {
+	arg, err := c.transcription(ctx, "guild", "transcription", c.id("author"), c.now)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.CreateStartedTranscription(ctx, arg); err == nil {
+		return fmt.Errorf("creating a transcription twice succeeded")
+	}
+
+	transcription, err := c.done(ctx, arg, "model", 12.5, 1.5)
+	if err != nil {
+		return err
+	}
+	if transcription.TranscriptionStatus != (db.NullTranscriptionStatus{TranscriptionStatus: db.TranscriptionStatusDone, Valid: true}) {
+		return fmt.Errorf("status is %+v, not done", transcription.TranscriptionStatus)
+	}
+	if transcription.GuildID != arg.GuildID || transcription.ResponseMessageID != arg.ResponseMessageID || transcription.AuthorID != arg.AuthorID || transcription.RequesterID != arg.RequesterID {
+		return fmt.Errorf("returned transcription doesn't match: %+v", transcription)
+	}
+	if !transcription.OriginalMessageTimestamp.Time.Equal(c.now) {
+		return fmt.Errorf("timestamp is %s, not %s", transcription.OriginalMessageTimestamp.Time, c.now)
+	}
+	if transcription.VoiceMessageAudioDuration != float8(12.5) || transcription.TranscriptionModel != text("model") || transcription.TranscriptionProcessingTime != float8(1.5) {
+		return fmt.Errorf("results weren't saved: %+v", transcription)
+	}
+
+	_, err = c.repo.UpdateTranscriptionFailed(ctx, db.UpdateTranscriptionFailedParams{
+		GuildID:           arg.GuildID,
+		ChannelID:         arg.ChannelID,
+		OriginalMessageID: c.id("missing-transcription"),
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failing a missing transcription returned %v, not ErrNotFound", err)
+	}
+	_, err = c.repo.UpdateTranscriptionDone(ctx, db.UpdateTranscriptionDoneParams{
+		GuildID:           arg.GuildID,
+		ChannelID:         arg.ChannelID,
+		OriginalMessageID: c.id("missing-transcription"),
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("finishing a missing transcription returned %v, not ErrNotFound", err)
+	}
+
+	return nil
+}
+
+func (c *checker) testMessageDeleted(ctx context.Context) error {
+	arg, err := c.transcription(ctx, "guild", "deleted", c.id("author"), c.now)
+	if err != nil {
+		return err
+	}
+
+	deleted := func(messageID string) (db.AsrTranscription, error) {
+		return c.repo.UpdateTranscriptionMessageDeleted(ctx, db.UpdateTranscriptionMessageDeletedParams{
+			GuildID:   arg.GuildID,
+			ChannelID: arg.ChannelID,
+			MessageID: messageID,
+		})
+	}
+
+	_, err = deleted(c.id("unrelated-message"))
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("deleting an unrelated message returned %v, not ErrNotFound", err)
+	}
+	_, err = c.repo.UpdateTranscriptionMessageDeleted(ctx, db.UpdateTranscriptionMessageDeletedParams{
+		GuildID:   arg.GuildID,
+		ChannelID: c.id("other-channel"),
+		MessageID: arg.OriginalMessageID,
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("deleting a message in another channel returned %v, not ErrNotFound", err)
+	}
+
+	transcription, err := deleted(arg.ResponseMessageID)
+	if err != nil {
+		return fmt.Errorf("deleting response: %w", err)
+	}
+	if !transcription.ResponseDeleted || transcription.OriginalMessageDeleted {
+		return fmt.Errorf("deleting the response set response deleted %t, original deleted %t", transcription.ResponseDeleted, transcription.OriginalMessageDeleted)
+	}
+
+	transcription, err = deleted(arg.OriginalMessageID)
+	if err != nil {
+		return fmt.Errorf("deleting original: %w", err)
+	}
+	if !transcription.ResponseDeleted || !transcription.OriginalMessageDeleted {
+		return fmt.Errorf("deleting the original set response deleted %t, original deleted %t", transcription.ResponseDeleted, transcription.OriginalMessageDeleted)
+	}
+
+	// the original first leaves the response alone
+	arg, err = c.transcription(ctx, "guild", "deleted-original", c.id("author"), c.now)
+	if err != nil {
+		return err
+	}
+	transcription, err = deleted(arg.OriginalMessageID)
+	if err != nil {
+		return fmt.Errorf("deleting original: %w", err)
+	}
+	if transcription.ResponseDeleted || !transcription.OriginalMessageDeleted {
+		return fmt.Errorf("deleting the original set response deleted %t, original deleted %t", transcription.ResponseDeleted, transcription.OriginalMessageDeleted)
+	}
+
+	return nil
+}
+
+func (c *checker) testTotals(ctx context.Context) error {
+	requesterID := c.id("totals-requester")
+	since := c.now.Add(-time.Hour)
+
+	old, err := c.transcription(ctx, "totals-guild", "totals-old", requesterID, since.Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+	if _, err := c.done(ctx, old, "model", 100, 1); err != nil {
+		return err
+	}
+	done, err := c.transcription(ctx, "totals-guild", "totals-done", requesterID, since)
+	if err != nil {
+		return err
+	}
+	if _, err := c.done(ctx, done, "model", 10, 1); err != nil {
+		return err
+	}
+	failed, err := c.transcription(ctx, "totals-guild", "totals-failed", requesterID, c.now)
+	if err != nil {
+		return err
+	}
+	if err := c.failed(ctx, failed); err != nil {
+		return err
+	}
+	if _, err := c.transcription(ctx, "totals-guild", "totals-started", requesterID, c.now); err != nil {
+		return err
+	}
+
+	want := db.GetRequesterTranscriptionTotalsRow{
+		Transcriptions:     3,
+		TranscriptionsDone: 1,
+		AudioDuration:      10,
+	}
+
+	requesterTotals, err := c.repo.GetRequesterTranscriptionTotals(ctx, db.GetRequesterTranscriptionTotalsParams{
+		RequesterID: requesterID,
+		Since:       timestamptz(since),
+	})
+	if err != nil {
+		return fmt.Errorf("getting requester totals: %w", err)
+	}
+	if requesterTotals != want {
+		return fmt.Errorf("requester totals are %+v, want %+v", requesterTotals, want)
+	}
+
+	guildTotals, err := c.repo.GetGuildTranscriptionTotals(ctx, db.GetGuildTranscriptionTotalsParams{
+		GuildID: c.id("totals-guild"),
+		Since:   timestamptz(since),
+	})
+	if err != nil {
+		return fmt.Errorf("getting guild totals: %w", err)
+	}
+	if guildTotals != db.GetGuildTranscriptionTotalsRow(want) {
+		return fmt.Errorf("guild totals are %+v, want %+v", guildTotals, want)
+	}
+
+	return nil
+}
+
+func floatsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func (c *checker) testUsageStats(ctx context.Context) error {
+	since := c.now.Add(-24 * time.Hour)
+	userID := c.id("stats-user")
+
+	results := []struct {
+		model          string
+		duration       float64
+		processingTime float64
+	}{
+		{"small", 60, 1},
+		{"small", 30, 2},
+		{"large", 120, 4},
+	}
+	for i, result := range results {
+		arg, err := c.transcription(ctx, "stats-guild", fmt.Sprintf("stats-%d", i), userID, c.now.Add(-time.Duration(i)*time.Minute))
+		if err != nil {
+			return err
+		}
+		if _, err := c.done(ctx, arg, result.model, result.duration, result.processingTime); err != nil {
+			return err
+		}
+	}
+	failed, err := c.transcription(ctx, "stats-guild", "stats-failed", userID, c.now)
+	if err != nil {
+		return err
+	}
+	if err := c.failed(ctx, failed); err != nil {
+		return err
+	}
+	if _, err := c.transcription(ctx, "stats-guild", "stats-old", userID, since.Add(-time.Hour)); err != nil {
+		return err
+	}
+
+	stats, err := c.repo.GetUsageStats(ctx, c.id("stats-guild"), since, map[string]float64{"large": 0.5})
+	if err != nil {
+		return fmt.Errorf("getting stats: %w", err)
+	}
+
+	if !stats.Since.Equal(since) {
+		return fmt.Errorf("since is %s, not %s", stats.Since, since)
+	}
+	total := stats.Total
+	if total.Transcriptions != 4 || total.Failed != 1 || !floatsEqual(total.AudioDuration, 210) {
+		return fmt.Errorf("totals are %+v", total)
+	}
+	// percentile_cont interpolates between 1, 2 and 4
+	if !floatsEqual(total.ProcessingTimeP50, 2) || !floatsEqual(total.ProcessingTimeP95, 3.8) {
+		return fmt.Errorf("total percentiles are %f and %f, want 2 and 3.8", total.ProcessingTimeP50, total.ProcessingTimeP95)
+	}
+	if !floatsEqual(total.EstimatedCost, 1) {
+		return fmt.Errorf("total cost is %f, want 1", total.EstimatedCost)
+	}
+
+	if len(stats.ByModel) != 3 {
+		return fmt.Errorf("got %d models, want small, large and none: %+v", len(stats.ByModel), stats.ByModel)
+	}
+	small := stats.ByModel[0]
+	if small.Key != "small" || small.Transcriptions != 2 || !floatsEqual(small.ProcessingTimeP50, 1.5) || small.EstimatedCost != 0 {
+		return fmt.Errorf("busiest model is %+v, want small", small)
+	}
+	for _, row := range stats.ByModel[1:] {
+		if row.Key == "large" && !floatsEqual(row.EstimatedCost, 1) {
+			return fmt.Errorf("large costs %f, want 1", row.EstimatedCost)
+		}
+		if row.Key == "" && (row.Transcriptions != 1 || row.Failed != 1 || row.ProcessingTimeP50 != 0) {
+			return fmt.Errorf("unfinished transcriptions are %+v", row)
+		}
+	}
+
+	var dayTranscriptions int64
+	for i, row := range stats.ByDay {
+		if _, err := time.Parse(time.DateOnly, row.Key); err != nil {
+			return fmt.Errorf("day key: %w", err)
+		}
+		if i > 0 && row.Key >= stats.ByDay[i-1].Key {
+			return fmt.Errorf("days aren't newest first: %+v", stats.ByDay)
+		}
+		dayTranscriptions += row.Transcriptions
+	}
+	if dayTranscriptions != total.Transcriptions {
+		return fmt.Errorf("days have %d transcriptions, want %d", dayTranscriptions, total.Transcriptions)
+	}
+
+	if stats.ByGuild != nil {
+		return fmt.Errorf("guild stats include other guilds: %+v", stats.ByGuild)
+	}
+
+	return nil
+}
+
+func (c *checker) testGuildSettings(ctx context.Context) error {
+	guildID := c.id("settings-guild")
+
+	settings, err := c.repo.GetGuildSettingsOrDefault(ctx, guildID)
+	if err != nil {
+		return fmt.Errorf("getting defaults: %w", err)
+	}
+	if settings.GuildID != guildID || settings.UserDailyAudioSeconds.Valid || settings.GuildDailyAudioSeconds.Valid || settings.LowConfidenceAction != db.LowConfidenceActionFlag {
+		return fmt.Errorf("defaults are %+v", settings)
+	}
+
+	arg := db.UpsertGuildSettingsParams{
+		GuildID:               guildID,
+		UserDailyAudioSeconds: float8(60),
+		LowConfidenceAction:   db.LowConfidenceActionSuppress,
+		UpdatedBy:             text(c.id("admin")),
+	}
+	inserted, err := c.repo.UpsertGuildSettings(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("inserting: %w", err)
+	}
+	if inserted.UserDailyAudioSeconds != float8(60) || inserted.GuildDailyAudioSeconds.Valid || inserted.LowConfidenceAction != db.LowConfidenceActionSuppress || inserted.UpdatedBy != arg.UpdatedBy || !inserted.UpdatedAt.Valid {
+		return fmt.Errorf("inserted settings are %+v", inserted)
+	}
+
+	arg.UserDailyAudioSeconds = pgtype.Float8{}
+	arg.GuildDailyAudioSeconds = float8(600)
+	arg.LowConfidenceAction = db.LowConfidenceActionFlag
+	if _, err := c.repo.UpsertGuildSettings(ctx, arg); err != nil {
+		return fmt.Errorf("updating: %w", err)
+	}
+
+	settings, err = c.repo.GetGuildSettingsOrDefault(ctx, guildID)
+	if err != nil {
+		return fmt.Errorf("getting settings: %w", err)
+	}
+	if settings.UserDailyAudioSeconds.Valid || settings.GuildDailyAudioSeconds != float8(600) || settings.LowConfidenceAction != db.LowConfidenceActionFlag {
+		return fmt.Errorf("updated settings are %+v", settings)
+	}
+
+	return nil
+}
+
+func (c *checker) testHistory(ctx context.Context) error {
+	userID := c.id("history-user")
+
+	if !c.repo.HistoryAvailable() {
+		_, err := c.repo.GetTranscriptHistory(ctx, userID, "", 10, 0)
+		if !errors.Is(err, store.ErrHistoryUnavailable) {
+			return fmt.Errorf("listing without history returned %v, not ErrHistoryUnavailable", err)
+		}
+		return nil
+	}
+
+	texts := []string{
+		"the orange cat sat down",
+		"nothing to see here",
+		"Orange, orange and ORANGE cats",
+	}
+	for i, transcriptText := range texts {
+		arg, err := c.transcription(ctx, "history-guild", fmt.Sprintf("history-%d", i), userID, c.now)
+		if err != nil {
+			return err
+		}
+		transcription, err := c.done(ctx, arg, "model", 1, 1)
+		if err != nil {
+			return err
+		}
+		err = c.repo.StoreTranscriptText(ctx, &transcription, userID, transcriptText)
+		if err != nil {
+			return fmt.Errorf("storing transcript: %w", err)
+		}
+		// created_at orders history
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries, err := c.repo.GetTranscriptHistory(ctx, userID, "", 2, 0)
+	if err != nil {
+		return fmt.Errorf("listing: %w", err)
+	}
+	if len(entries) != 2 || entries[0].Text != texts[2] || entries[1].Text != texts[1] {
+		return fmt.Errorf("first page is %+v, want the newest two", entries)
+	}
+	if entries[0].OriginalMessageID != c.id("history-2") || entries[0].GuildID != c.id("history-guild") {
+		return fmt.Errorf("entry is %+v", entries[0])
+	}
+
+	entries, err = c.repo.GetTranscriptHistory(ctx, userID, "", 2, 2)
+	if err != nil {
+		return fmt.Errorf("listing: %w", err)
+	}
+	if len(entries) != 1 || entries[0].Text != texts[0] {
+		return fmt.Errorf("second page is %+v, want the oldest", entries)
+	}
+
+	entries, err = c.repo.GetTranscriptHistory(ctx, userID, "orange", 10, 0)
+	if err != nil {
+		return fmt.Errorf("searching: %w", err)
+	}
+	if len(entries) != 2 || entries[0].Text != texts[2] || entries[1].Text != texts[0] {
+		return fmt.Errorf("search results are %+v, want the most relevant first", entries)
+	}
+
+	entries, err = c.repo.GetTranscriptHistory(ctx, userID, "orange see", 10, 0)
+	if err != nil {
+		return fmt.Errorf("searching: %w", err)
+	}
+	if len(entries) != 0 {
+		return fmt.Errorf("search needs every word, got %+v", entries)
+	}
+
+	entries, err = c.repo.GetTranscriptHistory(ctx, userID, "?!", 10, 0)
+	if err != nil {
+		return fmt.Errorf("searching: %w", err)
+	}
+	if len(entries) != len(texts) || entries[0].Text != texts[2] {
+		return fmt.Errorf("a query without words returned %+v, want everything newest first", entries)
+	}
+
+	entries, err = c.repo.GetTranscriptHistory(ctx, c.id("other-user"), "", 10, 0)
+	if err != nil {
+		return fmt.Errorf("listing: %w", err)
+	}
+	if len(entries) != 0 {
+		return fmt.Errorf("another user got %+v", entries)
+	}
+
+	return nil
+}
+
+func (c *checker) testPrivacy(ctx context.Context) error {
+	userID := c.id("privacy-user")
+	otherID := c.id("privacy-other")
+
+	if _, err := c.repo.GetOrCreateUser(ctx, userID); err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	err := c.repo.UpdateUserASREnabled(ctx, db.UpdateUserASREnabledParams{ID: userID, AsrEnabled: true})
+	if err != nil {
+		return fmt.Errorf("enabling asr: %w", err)
+	}
+
+	authored, err := c.transcription(ctx, "privacy-guild", "privacy-authored", userID, c.now.Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+	transcription, err := c.done(ctx, authored, "model", 5, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := c.transcription(ctx, "privacy-guild", "privacy-authored-2", userID, c.now); err != nil {
+		return err
+	}
+
+	// another user's voice message the user requested
+	requested := db.CreateStartedTranscriptionParams{
+		GuildID:                  c.id("privacy-guild"),
+		ChannelID:                c.id("channel"),
+		OriginalMessageID:        c.id("privacy-requested"),
+		OriginalMessageTimestamp: timestamptz(c.now),
+		ResponseMessageID:        c.id("privacy-requested-response"),
+		AuthorID:                 text(otherID),
+		RequesterID:              text(userID),
+	}
+	if err := c.repo.CreateStartedTranscription(ctx, requested); err != nil {
+		return fmt.Errorf("creating requested transcription: %w", err)
+	}
+
+	if c.repo.HistoryAvailable() {
+		err = c.repo.StoreTranscriptText(ctx, &transcription, userID, "private")
+		if err != nil {
+			return fmt.Errorf("storing transcript: %w", err)
+		}
+	}
+
+	export, err := c.repo.ExportUserData(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("exporting: %w", err)
+	}
+	if export.User == nil || export.User.ID != userID || !export.User.ASREnabled || export.User.ASREnabledTouchedAt == nil {
+		return fmt.Errorf("exported user is %+v", export.User)
+	}
+	if len(export.Transcriptions) != 2 || export.Transcriptions[0].OriginalMessageID != authored.OriginalMessageID {
+		return fmt.Errorf("exported transcriptions are %+v, want the two authored ones oldest first", export.Transcriptions)
+	}
+	if status := export.Transcriptions[0].TranscriptionStatus; status == nil || *status != string(db.TranscriptionStatusDone) {
+		return fmt.Errorf("exported status is %v", status)
+	}
+	if exported := export.Transcriptions[0]; exported.AuthorID == nil || *exported.AuthorID != userID || exported.RequesterID == nil || *exported.RequesterID != userID {
+		return fmt.Errorf("exported author and requester are %v and %v, want %s", exported.AuthorID, exported.RequesterID, userID)
+	}
+	if c.repo.HistoryAvailable() && (len(export.Transcripts) != 1 || export.Transcripts[0].Text != "private") {
+		return fmt.Errorf("exported transcripts are %+v", export.Transcripts)
+	}
+
+	deletion, err := c.repo.DeleteUserData(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("deleting: %w", err)
+	}
+	if !deletion.UserDeleted || len(deletion.Transcriptions) != 2 || len(deletion.RequestsCleared) != 1 {
+		return fmt.Errorf("deletion is %+v", deletion)
+	}
+	if c.repo.HistoryAvailable() && deletion.TranscriptsDeleted != 1 {
+		return fmt.Errorf("deleted %d transcripts, want 1", deletion.TranscriptsDeleted)
+	}
+
+	export, err = c.repo.ExportUserData(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("exporting: %w", err)
+	}
+	if export.User != nil || len(export.Transcriptions) != 0 || len(export.Transcripts) != 0 {
+		return fmt.Errorf("data is left after deleting: %+v", export)
+	}
+
+	totals, err := c.repo.GetRequesterTranscriptionTotals(ctx, db.GetRequesterTranscriptionTotalsParams{
+		RequesterID: userID,
+		Since:       timestamptz(c.now.Add(-time.Hour)),
+	})
+	if err != nil {
+		return fmt.Errorf("getting requester totals: %w", err)
+	}
+	if totals.Transcriptions != 0 {
+		return fmt.Errorf("user is still the requester of %d transcriptions", totals.Transcriptions)
+	}
+
+	otherExport, err := c.repo.ExportUserData(ctx, otherID)
+	if err != nil {
+		return fmt.Errorf("exporting other user: %w", err)
+	}
+	if len(otherExport.Transcriptions) != 1 {
+		return fmt.Errorf("other user's transcription was deleted")
+	}
+
+	deletion, err = c.repo.DeleteUserData(ctx, otherID)
+	if err != nil {
+		return fmt.Errorf("deleting other user: %w", err)
+	}
+	if deletion.UserDeleted {
+		return fmt.Errorf("deleted other user, who was never created")
+	}
+
+	return nil
+}
+
+func (c *checker) testRetention(ctx context.Context) error {
+	before := time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)
+	userID := c.id("retention-user")
+
+	old := []struct {
+		timestamp time.Time
+		duration  float64
+		failed    bool
+	}{
+		{time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC), 60, false},
+		{time.Date(2000, time.January, 2, 12, 0, 0, 0, time.UTC), 30, false},
+		{time.Date(2000, time.January, 2, 13, 0, 0, 0, time.UTC), 0, true},
+	}
+	var pruned []db.CreateStartedTranscriptionParams
+	for i, transcription := range old {
+		arg, err := c.transcription(ctx, "retention-guild", fmt.Sprintf("retention-%d", i), userID, transcription.timestamp)
+		if err != nil {
+			return err
+		}
+		if transcription.failed {
+			err = c.failed(ctx, arg)
+		} else {
+			_, err = c.done(ctx, arg, "small", transcription.duration, 1)
+		}
+		if err != nil {
+			return err
+		}
+		pruned = append(pruned, arg)
+	}
+	recent, err := c.transcription(ctx, "retention-guild", "retention-recent", userID, c.now)
+	if err != nil {
+		return err
+	}
+	if _, err := c.done(ctx, recent, "small", 10, 5); err != nil {
+		return err
+	}
+
+	// old transcriptions in someone's history are kept, in another guild so
+	// the stats below don't count them
+	historyUserID := c.id("retention-history-user")
+	var kept db.CreateStartedTranscriptionParams
+	if c.repo.HistoryAvailable() {
+		kept, err = c.transcription(ctx, "retention-history-guild", "retention-history", historyUserID, old[0].timestamp)
+		if err != nil {
+			return err
+		}
+		transcription, err := c.done(ctx, kept, "small", 1, 1)
+		if err != nil {
+			return err
+		}
+		err = c.repo.StoreTranscriptText(ctx, &transcription, historyUserID, "kept")
+		if err != nil {
+			return fmt.Errorf("storing transcript: %w", err)
+		}
+	}
+
+	// the database may have other old rows, so only the batch size and the
+	// total are checked
+	const batchSize = 2
+	var total int64
+	for {
+		n, err := c.repo.PruneTranscriptions(ctx, before, batchSize)
+		if err != nil {
+			return fmt.Errorf("pruning: %w", err)
+		}
+		if n > batchSize {
+			return fmt.Errorf("pruned %d transcriptions in a batch of %d", n, batchSize)
+		}
+		total += n
+		if n < batchSize {
+			break
+		}
+	}
+	if total < int64(len(old)) {
+		return fmt.Errorf("pruned %d transcriptions, want at least %d", total, len(old))
+	}
+
+	for _, arg := range pruned {
+		_, err := c.repo.UpdateTranscriptionFailed(ctx, db.UpdateTranscriptionFailedParams{
+			GuildID:           arg.GuildID,
+			ChannelID:         arg.ChannelID,
+			OriginalMessageID: arg.OriginalMessageID,
+		})
+		if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("updating pruned %s returned %v, want ErrNotFound", arg.OriginalMessageID, err)
+		}
+	}
+	if _, err := c.done(ctx, recent, "small", 10, 5); err != nil {
+		return fmt.Errorf("recent transcription was pruned: %w", err)
+	}
+	if c.repo.HistoryAvailable() {
+		if _, err := c.done(ctx, kept, "small", 1, 1); err != nil {
+			return fmt.Errorf("transcription in history was pruned: %w", err)
+		}
+		entries, err := c.repo.GetTranscriptHistory(ctx, historyUserID, "", 10, 0)
+		if err != nil {
+			return fmt.Errorf("listing history: %w", err)
+		}
+		if len(entries) != 1 || entries[0].Text != "kept" {
+			return fmt.Errorf("history after pruning is %+v, want the old transcript kept", entries)
+		}
+	}
+
+	stats, err := c.repo.GetUsageStats(ctx, c.id("retention-guild"), time.Date(1999, time.December, 31, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		return fmt.Errorf("getting stats: %w", err)
+	}
+	if stats.Total.Transcriptions != 4 || stats.Total.Failed != 1 || !floatsEqual(stats.Total.AudioDuration, 100) {
+		return fmt.Errorf("totals with pruned transcriptions are %+v", stats.Total)
+	}
+	// only the recent transcription has a processing time left
+	if !floatsEqual(stats.Total.ProcessingTimeP50, 5) {
+		return fmt.Errorf("median processing time is %f, want 5", stats.Total.ProcessingTimeP50)
+	}
+
+	days := map[string]int64{}
+	for _, row := range stats.ByDay {
+		days[row.Key] = row.Transcriptions
+	}
+	if days["2000-01-01"] != 1 || days["2000-01-02"] != 2 || len(days) != 3 {
+		return fmt.Errorf("days with pruned transcriptions are %+v", stats.ByDay)
+	}
+	if len(stats.ByModel) != 2 || stats.ByModel[0].Key != "small" || stats.ByModel[0].Transcriptions != 3 {
+		return fmt.Errorf("models with pruned transcriptions are %+v", stats.ByModel)
+	}
+
+	// 2000-01-01's rollup has a transcription from before since, so pruned
+	// transcriptions are only counted from the next day
+	stats, err = c.repo.GetUsageStats(ctx, c.id("retention-guild"), time.Date(2000, time.January, 1, 18, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		return fmt.Errorf("getting stats: %w", err)
+	}
+	if stats.Total.Transcriptions != 3 {
+		return fmt.Errorf("stats since the middle of a pruned day have %d transcriptions, want 3", stats.Total.Transcriptions)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/K3das/orange/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer creates spans for queries made while a trace is active.
+type queryTracer struct{}
+
+// queryName returns the sqlc query name from the `-- name: ` comment.
+func queryName(sql string) string {
+	line, _, _ := strings.Cut(sql, "\n")
+	name, ok := strings.CutPrefix(line, "-- name: ")
+	if !ok {
+		return "query"
+	}
+	name, _, _ = strings.Cut(name, " ")
+	return name
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	ctx, _ = tracing.Start(ctx, "db."+queryName(data.SQL),
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", data.SQL),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	tracing.End(trace.SpanFromContext(ctx), data.Err)
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/K3das/orange/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const tracerName = "github.com/K3das/orange"
+
+// Options configures exporting traces. The exporter itself is configured with
+// the standard OTEL_EXPORTER_OTLP_* environment variables, and sends to a
+// collector on localhost:4318 by default.
+type Options struct {
+	Enabled     bool   `env:"ENABLED"`
+	ServiceName string `env:"SERVICE_NAME" envDefault:"orange"`
+}
+
+// Setup installs a global tracer provider exporting over OTLP/HTTP. If tracing
+// is disabled spans are no-ops. The returned function flushes and stops the
+// exporter.
+func Setup(ctx context.Context, options Options, version string) (func(context.Context) error, error) {
+	if !options.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating exporter: %w", err)
+	}
+
+	attributes := []attribute.KeyValue{
+		semconv.ServiceName(options.ServiceName),
+	}
+	if version != "" {
+		attributes = append(attributes, semconv.ServiceVersion(version))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attributes...)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span. Spans that start a new trace add its ID to the log
+// context, so logs can be matched with traces.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	isRoot := !trace.SpanContextFromContext(ctx).IsValid()
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+
+	spanContext := span.SpanContext()
+	if isRoot && spanContext.IsValid() {
+		ctx = utils.LogContext(ctx, zap.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return ctx, span
+}
+
+// End ends the span, marking it as failed if err isn't nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}